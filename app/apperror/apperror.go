@@ -0,0 +1,113 @@
+package apperror
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Kind classifies an error, the error middleware picks the status code from it
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindNotFound
+	KindConflict
+	KindValidation
+	KindUnauthorized
+	KindForbidden
+	KindUnavailable
+	KindTooManyRequests
+)
+
+// Error is an error the client can be told about
+type Error struct {
+	Kind    Kind
+	Message string
+	Details interface{}
+	Err     error
+	// RetryAfter is sent in the Retry-After header when it's set
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Status returns the http status code of the error
+func (e *Error) Status() int {
+	switch e.Kind {
+	case KindNotFound:
+		return http.StatusNotFound
+	case KindConflict:
+		return http.StatusConflict
+	case KindValidation:
+		return http.StatusBadRequest
+	case KindUnauthorized:
+		return http.StatusUnauthorized
+	case KindForbidden:
+		return http.StatusForbidden
+	case KindUnavailable:
+		return http.StatusServiceUnavailable
+	case KindTooManyRequests:
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// NotFound is returned when the requested data doesn't exist
+func NotFound(message string) *Error {
+	return &Error{Kind: KindNotFound, Message: message}
+}
+
+// Conflict is returned when the data clashes with existing data, example: a username that is already taken
+func Conflict(message string) *Error {
+	return &Error{Kind: KindConflict, Message: message}
+}
+
+// Validation is returned when the request is invalid, details describe what is wrong
+func Validation(message string, details interface{}) *Error {
+	return &Error{Kind: KindValidation, Message: message, Details: details}
+}
+
+// Unauthorized is returned when the client isn't signed in
+func Unauthorized(message string) *Error {
+	return &Error{Kind: KindUnauthorized, Message: message}
+}
+
+// Forbidden is returned when the client is signed in but isn't allowed to do the action
+func Forbidden(message string) *Error {
+	return &Error{Kind: KindForbidden, Message: message}
+}
+
+// Unavailable is returned when a dependency like the database or the media store can't be reached
+func Unavailable(message string, err error) *Error {
+	return &Error{Kind: KindUnavailable, Message: message, Err: err}
+}
+
+// TooManyRequests is returned when the client has to wait before trying again, example: after failed logins
+func TooManyRequests(message string, retryAfter time.Duration) *Error {
+	return &Error{Kind: KindTooManyRequests, Message: message, RetryAfter: retryAfter}
+}
+
+// Internal wraps an unexpected error, its cause is logged but never sent to the client
+func Internal(err error) *Error {
+	return &Error{Kind: KindInternal, Message: "Something went wrong, please try again later", Err: err}
+}
+
+// From returns the Error wrapped in err, unknown errors become internal errors
+func From(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	return Internal(err)
+}
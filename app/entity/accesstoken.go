@@ -0,0 +1,56 @@
+package entity
+
+import (
+	"strings"
+	"time"
+)
+
+// Scopes of a PersonalAccessToken, a token can only be used on the routes that accept one of its scopes
+const (
+	ScopePhotosWrite   = "photos:write"
+	ScopeCommentsWrite = "comments:write"
+	// ScopeReadOnly is accepted by the read routes only, every other scope includes it
+	ScopeReadOnly = "read-only"
+)
+
+// AccessTokenScopes lists the scopes a token can be created with
+var AccessTokenScopes = []string{ScopePhotosWrite, ScopeCommentsWrite, ScopeReadOnly}
+
+// AccessTokenPrefix starts every personal access token, it tells them apart from the session tokens
+const AccessTokenPrefix = "mgp_"
+
+// PersonalAccessToken lets scripts act as the user without the password, only the hash of the token is stored
+type PersonalAccessToken struct {
+	Base
+	UserID uint
+	Name   string
+	// Scopes are separated by spaces
+	Scopes    string
+	TokenHash string
+	// ExpiresAt is nil when the token never expires
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+}
+
+// HasScope reports whether the token can be used on a route that accepts the scope
+func (t PersonalAccessToken) HasScope(scope string) bool {
+	for _, own := range strings.Fields(t.Scopes) {
+		if own == scope || scope == ScopeReadOnly {
+			return true
+		}
+	}
+	return false
+}
+
+// Expired reports whether the token can't be used anymore
+func (t PersonalAccessToken) Expired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
+
+// AccessTokenRequest is the body of the create token endpoint
+type AccessTokenRequest struct {
+	Name   string   `json:"name" form:"name" valid:"required~Token name is required,maxstringlength(100)~Token name must be 100 characters or less"`
+	Scopes []string `json:"scopes" form:"scopes"`
+	// ExpiresAt is optional, the token never expires without it
+	ExpiresAt *time.Time `json:"expires_at" form:"expires_at" time_format:"2006-01-02T15:04:05Z07:00"`
+}
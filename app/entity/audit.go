@@ -0,0 +1,25 @@
+package entity
+
+// Actions of an AuditLog
+const (
+	AuditAccountLocked   = "account_locked"
+	AuditAccountUnlocked = "account_unlocked"
+	AuditIPLocked        = "ip_locked"
+	AuditIdentityLinked  = "identity_linked"
+	AuditRoleChanged     = "role_changed"
+	AuditContentRemoved  = "content_removed"
+)
+
+// AuditLog records a security event, UserID is nil when the event isn't about a known user
+type AuditLog struct {
+	Base
+	UserID  *uint
+	Action  string
+	IP      string
+	Details string
+}
+
+// AccountUnlock is the body of the unlock endpoint
+type AccountUnlock struct {
+	Token string `json:"token" form:"token" valid:"required~Unlock token is required"`
+}
@@ -0,0 +1,12 @@
+package entity
+
+// LinkedIdentity is an account of an OpenID Connect provider the user logs in with,
+// the issuer and the subject of its ID tokens identify it
+type LinkedIdentity struct {
+	Base
+	UserID  uint
+	Issuer  string
+	Subject string
+	// Email is the email the provider shared when the identity was linked
+	Email string
+}
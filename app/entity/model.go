@@ -1,9 +1,9 @@
-package entity
-
-import "time"
-
-type Base struct {
-	ID uint `gorm:"primaryKey" json:"id"`
-	CreatedAt *time.Time `json:"created_at,omitempty"`
-	UpdatedAt *time.Time `json:"updated_at,omitempty"`
-}
+package entity
+
+import "time"
+
+type Base struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
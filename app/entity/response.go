@@ -1,41 +1,136 @@
-package entity
-
-import "time"
-
-//Response represents the all the response
-type Response struct {
-	Success bool        `json:"success" example:"true"`
-	Message string      `json:"message" example:"created"`
-	Data    interface{} `json:"data"`
-}
-
-type DataLogin struct {
-	Token string `json:"token" example:"eyJhbGciOiJI...."`
-}
-
-type DataRegister struct {
-	ID    uint   `json:"id" example:"1"`
-	Email string `json:"email" example:"user@mail.com"`
-	Uname string `json:"username" example:"user"`
-	Age   int    `json:"age" example:"18"`
-}
-
-type DataPhoto struct {
-	ID        uint        `json:"id" example:"1"`
-	Title     string      `json:"title"`
-	Caption   string      `json:"caption"`
-	UserID    uint        `json:"id_user" example:"1"`
-	Username  string      `json:"username"`
-	Photo_URL string      `json:"photo_url"`
-	CreatedAt *time.Time  `json:"created_at"`
-	UpdatedAt *time.Time  `json:"updated_at"`
-	Comment   interface{} `json:"comment"`
-}
-
-type DataComment struct {
-	ID        uint       `json:"id" example:"1"`
-	Message   string     `json:"message"`
-	Username  string     `json:"username"`
-	CreatedAt *time.Time `json:"created_at"`
-	UpdatedAt *time.Time `json:"updated_at"`
-}
+package entity
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Response represents the all the response
+type Response struct {
+	Success bool        `json:"success" example:"true"`
+	Message string      `json:"message" example:"created"`
+	Data    interface{} `json:"data"`
+	Meta    *Pagination `json:"meta,omitempty"`
+	Error   *Error      `json:"error,omitempty"`
+}
+
+// Pagination represents the position of the returned page in a list
+type Pagination struct {
+	Page       int   `json:"page" example:"1"`
+	PerPage    int   `json:"per_page" example:"20"`
+	Total      int64 `json:"total" example:"42"`
+	TotalPages int   `json:"total_pages" example:"3"`
+}
+
+// Error represents the reason why a request failed
+type Error struct {
+	Code    string      `json:"code" example:"not_found"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+// FieldError represents an invalid field of the request, it's sent in the details of validation errors
+type FieldError struct {
+	Field   string `json:"field" example:"email"`
+	Code    string `json:"code" example:"required"`
+	Message string `json:"message" example:"Your email is required"`
+}
+
+// ErrorResponse returns the response of a failed request, the error code is derived from the status, example: 404 is not_found
+func ErrorResponse(status int, message string) Response {
+	code := strings.ToLower(strings.ReplaceAll(http.StatusText(status), " ", "_"))
+
+	return Response{
+		Success: false,
+		Message: message,
+		Data:    nil,
+		Error:   &Error{Code: code},
+	}
+}
+
+type DataLogin struct {
+	Token        string `json:"token" example:"eyJhbGciOiJI...."`
+	ExpiresIn    int64  `json:"expires_in" example:"900"`
+	RefreshToken string `json:"refresh_token" example:"Xq3v9...."`
+}
+
+// DataChallenge is returned by the login instead of the tokens when the account has two-factor authentication
+type DataChallenge struct {
+	MFARequired    bool   `json:"mfa_required" example:"true"`
+	ChallengeToken string `json:"challenge_token" example:"Xq3v9...."`
+	ExpiresIn      int64  `json:"expires_in" example:"300"`
+}
+
+// DataTOTPEnrollment is the secret to add to an authenticator app, by hand, with the URI or by scanning the QR code
+type DataTOTPEnrollment struct {
+	Secret string `json:"secret" example:"JBSWY3DPEHPK3PXP"`
+	URI    string `json:"otpauth_uri" example:"otpauth://totp/MyGram:user@mail.com?issuer=MyGram&secret=JBSWY3DPEHPK3PXP"`
+	// QRCode is a PNG data URI, it can be used as the src of an img
+	QRCode string `json:"qr_code" example:"data:image/png;base64,iVBORw0KGgo...."`
+}
+
+// DataRecoveryCodes are shown once, each code can be used instead of a TOTP code once
+type DataRecoveryCodes struct {
+	RecoveryCodes []string `json:"recovery_codes" example:"k3j9x-m2p7q"`
+}
+
+// DataAccessToken describes a personal access token, Token is only sent once when the token is created
+type DataAccessToken struct {
+	ID         uint       `json:"id" example:"1"`
+	Name       string     `json:"name" example:"photo uploader"`
+	Scopes     []string   `json:"scopes" example:"photos:write"`
+	Token      string     `json:"token,omitempty" example:"mgp_Xq3v9...."`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  *time.Time `json:"created_at"`
+}
+
+// DataSession describes a login of the user, Current is true for the session of the request
+type DataSession struct {
+	ID         uint       `json:"id" example:"1"`
+	UserAgent  string     `json:"user_agent" example:"Mozilla/5.0 (X11; Linux x86_64)"`
+	IP         string     `json:"ip" example:"203.0.113.7"`
+	Current    bool       `json:"current" example:"true"`
+	CreatedAt  *time.Time `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+}
+
+type DataRegister struct {
+	ID    uint   `json:"id" example:"1"`
+	Email string `json:"email" example:"user@mail.com"`
+	Uname string `json:"username" example:"user"`
+	Age   int    `json:"age" example:"18"`
+	// EmailVerified is false until the verification link mailed to the user is opened
+	EmailVerified bool `json:"email_verified" example:"false"`
+}
+
+// DataUser describes a user to the admins
+type DataUser struct {
+	ID            uint       `json:"id" example:"1"`
+	Username      string     `json:"username" example:"user"`
+	Email         string     `json:"email" example:"user@mail.com"`
+	Age           uint       `json:"age" example:"18"`
+	Role          string     `json:"role" example:"user"`
+	EmailVerified bool       `json:"email_verified" example:"true"`
+	CreatedAt     *time.Time `json:"created_at"`
+}
+
+type DataPhoto struct {
+	ID        uint        `json:"id" example:"1"`
+	Title     string      `json:"title"`
+	Caption   string      `json:"caption"`
+	UserID    uint        `json:"id_user" example:"1"`
+	Username  *string     `json:"username"` // null when the user no longer exists
+	Photo_URL string      `json:"photo_url"`
+	CreatedAt *time.Time  `json:"created_at"`
+	UpdatedAt *time.Time  `json:"updated_at"`
+	Comment   interface{} `json:"comment"`
+}
+
+type DataComment struct {
+	ID        uint       `json:"id" example:"1"`
+	Message   string     `json:"message"`
+	Username  *string    `json:"username"` // null when the user no longer exists
+	CreatedAt *time.Time `json:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at"`
+}
@@ -0,0 +1,15 @@
+package entity
+
+import "time"
+
+// Session is a login of the user on a device, its refresh tokens share its FamilyID and its access tokens carry its id.
+// Removing the session logs the device out.
+type Session struct {
+	Base
+	UserID    uint
+	FamilyID  string
+	UserAgent string
+	// IP is the address the session was last seen from
+	IP         string
+	LastSeenAt time.Time
+}
@@ -0,0 +1,54 @@
+package entity
+
+import "time"
+
+// RefreshToken is exchanged for a new access token, only the hash of the token is stored
+type RefreshToken struct {
+	Base
+	UserID uint
+	// FamilyID is shared by every token rotated from the same login
+	FamilyID  string
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+}
+
+// RefreshRequest is the body of the refresh endpoint
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" form:"refresh_token" valid:"required~Refresh token is required"`
+}
+
+// Purposes of a UserToken, a token is only accepted by the flow it was issued for
+const (
+	PurposePasswordReset     = "password_reset"
+	PurposeEmailVerification = "email_verification"
+	PurposeLoginChallenge    = "login_challenge"
+	PurposeAccountUnlock     = "account_unlock"
+)
+
+// UserToken is a one-time token sent by mail, only the hash of the token is stored
+type UserToken struct {
+	Base
+	UserID    uint
+	Purpose   string
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
+
+// PasswordResetRequest is the body of the request-reset endpoint
+type PasswordResetRequest struct {
+	Email string `json:"email" form:"email" valid:"required~Your email is required,email~Invalid email format"`
+}
+
+// PasswordResetConfirm is the body of the confirm-reset endpoint
+type PasswordResetConfirm struct {
+	Token    string `json:"token" form:"token" valid:"required~Reset token is required"`
+	Password string `json:"password" form:"password" valid:"required~Your password is required,minstringlength(6)~Password must be 6 characters or more"`
+}
+
+// EmailVerification is the body of the verify endpoint
+type EmailVerification struct {
+	Token string `json:"token" form:"token" valid:"required~Verification token is required"`
+}
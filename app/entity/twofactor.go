@@ -0,0 +1,22 @@
+package entity
+
+import "time"
+
+// RecoveryCode replaces a TOTP code once, only the hash of the code is stored
+type RecoveryCode struct {
+	Base
+	UserID   uint
+	CodeHash string
+	UsedAt   *time.Time
+}
+
+// TOTPCode is the body of the endpoints that need a code of the authenticator app
+type TOTPCode struct {
+	Code string `json:"code" form:"code" valid:"required~Code is required"`
+}
+
+// LoginChallenge is the body of the second step of the login, the code is a TOTP code or a recovery code
+type LoginChallenge struct {
+	ChallengeToken string `json:"challenge_token" form:"challenge_token" valid:"required~Challenge token is required"`
+	Code           string `json:"code" form:"code" valid:"required~Code is required"`
+}
@@ -1,30 +1,72 @@
-package entity
-
-import (
-	"MyGramAPI/pkg/helpers"
-
-	"github.com/asaskevich/govalidator"
-	"gorm.io/gorm"
-)
-
-// User represents the model for a user
-type User struct {
-	//uint 32bit dan tidak boleh minus
-	Base
-	Username string `gorm:"not null;uniqueIndex" json:"username" form:"username" valid:"required~Your username is required"`
-	Email    string `gorm:"not null;uniqueIndex" json:"email" form:"email" valid:"required~Your email is required,email~Invalid email format"`
-	Password string `gorm:"not null" json:"password" form:"password" valid:"required~Your password is required,minstringlength(6)~Password must be 6 characters or more"`
-	Age      uint   `gorm:"not null" json:"age" form:"age" valid:"required~Your age is required,range(9|60)~Your age should be above 8 years old"`
-}
-
-func (u *User) BeforeCreate(tx *gorm.DB) (err error) {
-	_, errCreate := govalidator.ValidateStruct(u)
-
-	if errCreate != nil {
-		err = errCreate
-		return
-	}
-
-	u.Password = helpers.HashPass(u.Password)
-	return nil
-}
+package entity
+
+import (
+	"MyGramAPI/pkg/helpers"
+
+	"github.com/asaskevich/govalidator"
+	"gorm.io/gorm"
+)
+
+// Roles of a User, a moderator can remove any photo or comment and an admin can also manage the users
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// Roles lists every valid role
+var Roles = []string{RoleUser, RoleModerator, RoleAdmin}
+
+// User represents the model for a user
+type User struct {
+	//uint 32bit dan tidak boleh minus
+	Base
+	Username string `gorm:"not null;uniqueIndex" json:"username" form:"username" valid:"required~Your username is required"`
+	Email    string `gorm:"not null;uniqueIndex" json:"email" form:"email" valid:"required~Your email is required,email~Invalid email format"`
+	Password string `gorm:"not null" json:"password" form:"password" valid:"required~Your password is required,minstringlength(6)~Password must be 6 characters or more"`
+	Age      uint   `gorm:"not null" json:"age" form:"age" valid:"required~Your age is required,range(9|60)~Your age should be above 8 years old"`
+	// EmailVerified is set once the link mailed on register is opened, it's never bound from a request
+	EmailVerified bool `gorm:"not null;default:false" json:"-" form:"-"`
+	// TOTPSecret is set on enroll, the login only asks for a code once TOTPEnabled is confirmed
+	TOTPSecret  string `gorm:"not null;default:''" json:"-" form:"-"`
+	TOTPEnabled bool   `gorm:"not null;default:false" json:"-" form:"-"`
+	// TOTPLastStep is the time step of the last accepted code, the codes of that step and the earlier ones can't be used again
+	TOTPLastStep int64 `gorm:"not null;default:0" json:"-" form:"-"`
+	// Role is only changed by an admin or the role command, it's never bound from a request
+	Role string `gorm:"not null;default:'user'" json:"-" form:"-"`
+}
+
+// CanModerate reports whether the user may remove the photos and comments of others
+func (u User) CanModerate() bool {
+	return u.Role == RoleModerator || u.Role == RoleAdmin
+}
+
+// ValidRole reports whether role is one of Roles
+func ValidRole(role string) bool {
+	for _, valid := range Roles {
+		if role == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// RoleRequest is the body of the endpoint changing the role of a user
+type RoleRequest struct {
+	Role string `json:"role" form:"role" valid:"required~Role is required"`
+}
+
+func (u *User) BeforeCreate(tx *gorm.DB) (err error) {
+	_, errCreate := govalidator.ValidateStruct(u)
+
+	if errCreate != nil {
+		err = errCreate
+		return
+	}
+
+	if u.Role == "" {
+		u.Role = RoleUser
+	}
+	u.Password, err = helpers.HashPass(u.Password)
+	return err
+}
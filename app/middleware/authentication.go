@@ -1,26 +1,122 @@
-package middleware
-
-import (
-	"MyGramAPI/app/entity"
-	"MyGramAPI/pkg/helpers"
-	"net/http"
-
-	"github.com/gin-gonic/gin"
-)
-
-func Authentication() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		verifyToken, err := helpers.VerifyToken(c)
-
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, entity.Response{
-				Success: false,
-				Message: err.Error(),
-				Data:    nil,
-			})
-			return
-		}
-		c.Set("userData", verifyToken)
-		c.Next()
-	}
-}
+package middleware
+
+import (
+	"MyGramAPI/app/apperror"
+	"MyGramAPI/app/entity"
+	"MyGramAPI/app/repository"
+	"MyGramAPI/pkg/helpers"
+	"MyGramAPI/pkg/revocation"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// lastUsedInterval is how stale the last use of a personal access token or a session can get, so not every request writes it
+const lastUsedInterval = time.Minute
+
+// Authentication accepts the signed tokens that are not revoked and whose session still exists, their claims are set as userData.
+// A personal access token is only accepted when it has one of the scopes, without scopes the route needs a login.
+func Authentication(revocations revocation.Store, accessTokens repository.PersonalAccessTokenRepository, sessions repository.SessionRepository, scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok && strings.HasPrefix(token, entity.AccessTokenPrefix) {
+			claims, err := accessTokenClaims(c, accessTokens, token, scopes)
+			if err != nil {
+				abort(c, err)
+				return
+			}
+
+			c.Set("userData", claims)
+			c.Next()
+			return
+		}
+
+		verifyToken, err := helpers.VerifyToken(c)
+
+		if err != nil {
+			abort(c, apperror.Unauthorized(err.Error()))
+			return
+		}
+
+		revoked, err := revocations.IsRevoked(c.Request.Context(), verifyToken.Id, verifyToken.UserID(), time.Unix(verifyToken.IssuedAt, 0))
+		if err != nil {
+			abort(c, apperror.Unavailable("Token revocation store is unavailable, please try again later", err))
+			return
+		}
+		if revoked {
+			abort(c, apperror.Unauthorized("Token has been revoked, please log in again"))
+			return
+		}
+
+		if err := checkSession(c, sessions, verifyToken); err != nil {
+			abort(c, err)
+			return
+		}
+
+		c.Set("userData", verifyToken)
+		c.Next()
+	}
+}
+
+// checkSession rejects the token when its session was removed. Every token must carry its session, a token without one
+// was issued before the sessions were recorded and is refused too, its user only has to log in again.
+func checkSession(c *gin.Context, sessions repository.SessionRepository, claims *helpers.Claims) error {
+	if claims.SessionID == 0 {
+		return apperror.Unauthorized("Session has ended, please log in again")
+	}
+
+	Session, err := sessions.FindByID(c.Request.Context(), claims.SessionID)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && Session.UserID != claims.UserID()) {
+		return apperror.Unauthorized("Session has ended, please log in again")
+	}
+	if err != nil {
+		return apperror.Unavailable("Database is unavailable, please try again later", err)
+	}
+
+	now := time.Now()
+	if now.Sub(Session.LastSeenAt) >= lastUsedInterval {
+		if err := sessions.Touch(c.Request.Context(), Session.ID, now, c.ClientIP()); err != nil {
+			log.Printf("error updating the last use of session %d: %v", Session.ID, err)
+		}
+	}
+	return nil
+}
+
+// accessTokenClaims checks the personal access token and returns the claims of its user
+func accessTokenClaims(c *gin.Context, accessTokens repository.PersonalAccessTokenRepository, token string, scopes []string) (*helpers.Claims, error) {
+	ctx := c.Request.Context()
+
+	PersonalAccessToken, err := accessTokens.FindByHash(ctx, helpers.HashToken(token))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, apperror.Unauthorized("Access token is invalid or has been revoked")
+	}
+	if err != nil {
+		return nil, apperror.Unavailable("Database is unavailable, please try again later", err)
+	}
+
+	now := time.Now()
+	if PersonalAccessToken.Expired(now) {
+		return nil, apperror.Unauthorized("Access token has expired")
+	}
+
+	if len(scopes) == 0 {
+		return nil, apperror.Forbidden("Access tokens can't be used here, please log in")
+	}
+	allowed := false
+	for _, scope := range scopes {
+		allowed = allowed || PersonalAccessToken.HasScope(scope)
+	}
+	if !allowed {
+		return nil, apperror.Forbidden("Access token is missing the scope " + strings.Join(scopes, " or "))
+	}
+
+	if PersonalAccessToken.LastUsedAt == nil || now.Sub(*PersonalAccessToken.LastUsedAt) >= lastUsedInterval {
+		if err := accessTokens.Touch(ctx, PersonalAccessToken.ID, now); err != nil {
+			log.Printf("error updating the last use of access token %d: %v", PersonalAccessToken.ID, err)
+		}
+	}
+
+	return helpers.AccessTokenClaims(PersonalAccessToken.UserID, strings.Fields(PersonalAccessToken.Scopes)), nil
+}
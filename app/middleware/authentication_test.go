@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"MyGramAPI/app/entity"
+	"MyGramAPI/app/repository"
+	"MyGramAPI/pkg/config"
+	"MyGramAPI/pkg/helpers"
+	"MyGramAPI/pkg/revocation"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestAuthenticationChecksTheSession checks that a token is only accepted while the session it was issued for exists
+func TestAuthenticationChecksTheSession(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+	repos := repository.NewMemory()
+
+	cfg := config.Default().JWT
+	cfg.Secret = "secret"
+	if err := helpers.InitJWT(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	users := map[string]entity.User{}
+	for _, name := range []string{"alice", "bob"} {
+		User := entity.User{Username: name, Email: name + "@example.com", Password: "secret123", Age: 20}
+		if err := repos.Users.Create(ctx, &User); err != nil {
+			t.Fatal(err)
+		}
+		users[name] = User
+	}
+
+	// session creates a session of the user last seen an hour ago
+	session := func(user string) entity.Session {
+		Session := entity.Session{UserID: users[user].ID, FamilyID: user, LastSeenAt: time.Now().Add(-time.Hour)}
+		if err := repos.Sessions.Create(ctx, &Session); err != nil {
+			t.Fatal(err)
+		}
+		return Session
+	}
+	current, ended, other := session("alice"), session("alice"), session("bob")
+	if err := repos.Sessions.Delete(ctx, users["alice"].ID, ended.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	router := gin.New()
+	router.Use(Errors())
+	router.GET("/me", Authentication(revocation.NewMemory(), repos.AccessTokens, repos.Sessions), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		name      string
+		sessionID uint
+		want      int
+	}{
+		{"valid session", current.ID, http.StatusNoContent},
+		{"ended session", ended.ID, http.StatusUnauthorized},
+		{"missing session", 99, http.StatusUnauthorized},
+		{"without a session", 0, http.StatusUnauthorized},
+		{"session of another user", other.ID, http.StatusUnauthorized},
+	}
+	for _, test := range tests {
+		request := httptest.NewRequest(http.MethodGet, "/me", nil)
+		request.Header.Set("Authorization", "Bearer "+helpers.GenerateToken(users["alice"].ID, test.sessionID, "alice@example.com", nil))
+		recorder := httptest.NewRecorder()
+
+		router.ServeHTTP(recorder, request)
+		if recorder.Code != test.want {
+			t.Errorf("%s: got status %d, want %d: %s", test.name, recorder.Code, test.want, recorder.Body)
+		}
+	}
+
+	// the accepted request is the last use of the session
+	Session, err := repos.Sessions.FindByID(ctx, current.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(Session.LastSeenAt) > time.Minute {
+		t.Errorf("got the session last seen at %s, want now", Session.LastSeenAt)
+	}
+}
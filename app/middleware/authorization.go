@@ -1,94 +1,75 @@
-package middleware
-
-import (
-	"MyGramAPI/app/entity"
-	"MyGramAPI/pkg/database"
-	"net/http"
-	"strconv"
-
-	"github.com/dgrijalva/jwt-go"
-	"github.com/gin-gonic/gin"
-)
-
-func Authorization(endpoint string) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		db, _ := database.Connect()
-		param, err := strconv.Atoi(c.Param("id"))
-		if err != nil {
-			c.AbortWithStatusJSON(http.StatusBadRequest, entity.Response{
-				Success: false,
-				Message: "Invalid parameter",
-				Data:    nil,
-			})
-			return
-		}
-		userData := c.MustGet("userData").(jwt.MapClaims)
-		userID := uint(userData["id"].(float64))
-
-		switch endpoint {
-		case "photo":
-			Entity := entity.Photo{}
-			err := db.Select("my_gram_user_id").First(&Entity, uint(param)).Error
-
-			if err != nil {
-				c.AbortWithStatusJSON(http.StatusNotFound, entity.Response{
-					Success: false,
-					Message: "Invalid parameter",
-					Data:    nil,
-				})
-				return
-			}
-
-			if Entity.UserID != userID {
-				c.AbortWithStatusJSON(http.StatusUnauthorized, entity.Response{
-					Success: false,
-					Message: "You are not allowed to access this data",
-					Data:    nil,
-				})
-			}
-		case "comment":
-			Entity := entity.Comment{}
-			err := db.Select("my_gram_user_id").First(&Entity, uint(param)).Error
-
-			if err != nil {
-				c.AbortWithStatusJSON(http.StatusNotFound, entity.Response{
-					Success: false,
-					Message: "Data not found or exist",
-					Data:    nil,
-				})
-				return
-			}
-
-			if Entity.UserID != userID {
-				c.AbortWithStatusJSON(http.StatusUnauthorized, entity.Response{
-					Success: false,
-					Message: "You are not allowed to access this data",
-					Data:    nil,
-				})
-			}
-		case "socialMedia":
-			Entity := entity.SocialMedia{}
-			err := db.Select("my_gram_user_id").First(&Entity, uint(param)).Error
-
-			if err != nil {
-				c.AbortWithStatusJSON(http.StatusNotFound, entity.Response{
-					Success: false,
-					Message: "Data not found or exist",
-					Data:    nil,
-				})
-				return
-			}
-
-			if Entity.UserID != userID {
-				c.AbortWithStatusJSON(http.StatusUnauthorized, entity.Response{
-					Success: false,
-					Message: "You are not allowed to access this data",
-					Data:    nil,
-				})
-			}
-		default:
-
-		}
-
-	}
-}
+package middleware
+
+import (
+	"MyGramAPI/app/apperror"
+	"MyGramAPI/app/policy"
+	"MyGramAPI/app/repository"
+	"MyGramAPI/pkg/helpers"
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Authorization lets the request through when the policy of the resource allows the action on the record of the id parameter.
+// The role of the user is read from the database, so a change applies right away.
+func Authorization(users repository.UserRepository, policies *policy.Engine, resource string, action policy.Action) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil || id < 1 {
+			abort(c, apperror.Validation("Invalid parameter", nil))
+			return
+		}
+
+		userData := c.MustGet("userData").(*helpers.Claims)
+		User, err := users.FindByID(ctx, userData.UserID())
+		if err != nil {
+			abort(c, userError(err))
+			return
+		}
+
+		err = policies.Authorize(ctx, policy.Subject{UserID: User.ID, Role: User.Role}, resource, uint(id), action)
+		switch {
+		case err == nil:
+		case errors.Is(err, policy.ErrForbidden):
+			abort(c, apperror.Forbidden("You are not allowed to access this data"))
+		case errors.Is(err, repository.ErrNotFound):
+			abort(c, apperror.NotFound("Data not found or exist"))
+		case errors.Is(err, repository.ErrUnavailable):
+			abort(c, apperror.Unavailable("Database is unavailable, please try again later", err))
+		default:
+			abort(c, apperror.Internal(err))
+		}
+	}
+}
+
+// RequireRole rejects the users who don't have one of the roles. The role is read from the database,
+// so a change applies right away.
+func RequireRole(users repository.UserRepository, roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userData := c.MustGet("userData").(*helpers.Claims)
+		User, err := users.FindByID(c.Request.Context(), userData.UserID())
+		if err != nil {
+			abort(c, userError(err))
+			return
+		}
+
+		for _, role := range roles {
+			if User.Role == role {
+				return
+			}
+		}
+		abort(c, apperror.Forbidden("You are not allowed to access this data"))
+	}
+}
+
+// userError converts the error of the lookup of the signed in user, a deleted user has to sign in again
+// and anything else means the database is unreachable
+func userError(err error) error {
+	if errors.Is(err, repository.ErrNotFound) {
+		return apperror.Unauthorized("sign in to proceed")
+	}
+	return apperror.Unavailable("Database is unavailable, please try again later", err)
+}
@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"MyGramAPI/app/entity"
+	"MyGramAPI/app/policy"
+	"MyGramAPI/app/repository"
+	"MyGramAPI/pkg/helpers"
+	"MyGramAPI/pkg/revocation"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestAccessTokenScopesAndPolicies checks that a personal access token needs the scope of the route
+// and that the policy still applies to the user of the token
+func TestAccessTokenScopesAndPolicies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+	repos := repository.NewMemory()
+
+	users := map[string]entity.User{}
+	for _, name := range []string{"alice", "eve", "moderator"} {
+		User := entity.User{Username: name, Email: name + "@example.com", Password: "secret123", Age: 20}
+		if err := repos.Users.Create(ctx, &User); err != nil {
+			t.Fatal(err)
+		}
+		users[name] = User
+	}
+	if err := repos.Users.UpdateRole(ctx, users["moderator"].ID, entity.RoleModerator); err != nil {
+		t.Fatal(err)
+	}
+
+	Photo := entity.Photo{Title: "title", Photo_URL: "http://example.com", UserID: users["alice"].ID}
+	if err := repos.Photos.Create(ctx, &Photo); err != nil {
+		t.Fatal(err)
+	}
+
+	// token creates a personal access token of the user with the scope
+	token := func(user, scope string) string {
+		token := entity.AccessTokenPrefix + user + "-" + scope
+		PersonalAccessToken := entity.PersonalAccessToken{UserID: users[user].ID, Name: scope, Scopes: scope, TokenHash: helpers.HashToken(token)}
+		if err := repos.AccessTokens.Create(ctx, &PersonalAccessToken); err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	router := gin.New()
+	router.Use(Errors())
+	router.DELETE("/photos/:id",
+		Authentication(revocation.NewMemory(), repos.AccessTokens, repos.Sessions, entity.ScopePhotosWrite),
+		Authorization(repos.Users, policy.Default(repos), policy.ResourcePhoto, policy.ActionDelete),
+		func(c *gin.Context) { c.Status(http.StatusNoContent) },
+	)
+
+	tests := []struct {
+		name  string
+		token string
+		want  int
+	}{
+		{"owner with the scope", token("alice", entity.ScopePhotosWrite), http.StatusNoContent},
+		{"owner with another scope", token("alice", entity.ScopeCommentsWrite), http.StatusForbidden},
+		{"other user with the scope", token("eve", entity.ScopePhotosWrite), http.StatusForbidden},
+		{"moderator with the scope", token("moderator", entity.ScopePhotosWrite), http.StatusNoContent},
+		{"moderator with another scope", token("moderator", entity.ScopeCommentsWrite), http.StatusForbidden},
+		{"unknown token", entity.AccessTokenPrefix + "unknown", http.StatusUnauthorized},
+	}
+	for _, test := range tests {
+		request := httptest.NewRequest(http.MethodDelete, "/photos/"+strconv.Itoa(int(Photo.ID)), nil)
+		request.Header.Set("Authorization", "Bearer "+test.token)
+		recorder := httptest.NewRecorder()
+
+		router.ServeHTTP(recorder, request)
+		if recorder.Code != test.want {
+			t.Errorf("%s: got status %d, want %d: %s", test.name, recorder.Code, test.want, recorder.Body)
+		}
+	}
+}
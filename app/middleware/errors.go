@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"MyGramAPI/app/apperror"
+	"MyGramAPI/app/entity"
+	"log"
+	"math"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Errors sends the last error added with c.Error as the response, so handlers only have to return typed errors
+func Errors() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		err := apperror.From(c.Errors.Last().Err)
+		if err.Kind == apperror.KindInternal || err.Kind == apperror.KindUnavailable {
+			log.Printf("%s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+		}
+
+		if err.RetryAfter > 0 {
+			// the header is in whole seconds, rounded up so the client doesn't retry too early
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(err.RetryAfter.Seconds()))))
+		}
+
+		response := entity.ErrorResponse(err.Status(), err.Message)
+		response.Error.Details = err.Details
+
+		c.JSON(err.Status(), response)
+	}
+}
+
+// abort stops the chain, the error is sent by the Errors middleware
+func abort(c *gin.Context, err error) {
+	c.Error(err)
+	c.Abort()
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"MyGramAPI/app/apperror"
+	"MyGramAPI/app/repository"
+	"MyGramAPI/pkg/helpers"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VerifiedEmail rejects the users who haven't verified their email yet, nothing is checked when required is false.
+// The state is read from the database, so it applies as soon as the email is verified.
+func VerifiedEmail(users repository.UserRepository, required bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !required {
+			return
+		}
+
+		userData := c.MustGet("userData").(*helpers.Claims)
+		User, err := users.FindByID(c.Request.Context(), userData.UserID())
+
+		if errors.Is(err, repository.ErrNotFound) {
+			abort(c, apperror.Unauthorized("sign in to proceed"))
+			return
+		}
+		if err != nil {
+			abort(c, apperror.Unavailable("Database is unavailable, please try again later", err))
+			return
+		}
+
+		if !User.EmailVerified {
+			abort(c, apperror.Forbidden("Please verify your email first"))
+			return
+		}
+	}
+}
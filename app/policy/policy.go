@@ -0,0 +1,106 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrForbidden is returned when no rule of the policy allows the action
+var ErrForbidden = errors.New("action not allowed")
+
+// Action is what a request does to a resource
+type Action string
+
+const (
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+)
+
+// Subject is the user making the request
+type Subject struct {
+	UserID uint
+	Role   string
+}
+
+// Resource is the part of a record the rules look at
+type Resource struct {
+	OwnerID uint
+	// ParentOwnerID is the owner of the record this one belongs to, example: the owner of the photo of a comment
+	ParentOwnerID uint
+}
+
+// Rule allows an action on a resource when it returns true
+type Rule func(subject Subject, resource Resource) bool
+
+// Lookup finds the resource with the id, its errors are returned as they are by Authorize
+type Lookup func(ctx context.Context, id uint) (Resource, error)
+
+// Policy decides who can act on one type of resource, an action is allowed when any of its rules allows it.
+// An action without rules is never allowed.
+type Policy struct {
+	Lookup Lookup
+	Rules  map[Action][]Rule
+}
+
+// Engine evaluates the policies registered for each type of resource
+type Engine struct {
+	policies map[string]Policy
+}
+
+// New returns an engine without policies
+func New() *Engine {
+	return &Engine{policies: map[string]Policy{}}
+}
+
+// Register sets the policy of the type of resource, it replaces the previous one
+func (e *Engine) Register(resource string, policy Policy) {
+	e.policies[resource] = policy
+}
+
+// Authorize returns nil when the subject may do the action on the resource with the id, ErrForbidden when no rule allows it
+// and the error of the lookup when the resource can't be found
+func (e *Engine) Authorize(ctx context.Context, subject Subject, resource string, id uint, action Action) error {
+	policy, ok := e.policies[resource]
+	if !ok {
+		return fmt.Errorf("no policy is registered for %q", resource)
+	}
+
+	Resource, err := policy.Lookup(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	for _, rule := range policy.Rules[action] {
+		if rule(subject, Resource) {
+			return nil
+		}
+	}
+	return ErrForbidden
+}
+
+// Owner allows the owner of the resource
+func Owner() Rule {
+	return func(subject Subject, resource Resource) bool {
+		return resource.OwnerID != 0 && resource.OwnerID == subject.UserID
+	}
+}
+
+// ParentOwner allows the owner of the record the resource belongs to, example: the owner of a photo can moderate its comments
+func ParentOwner() Rule {
+	return func(subject Subject, resource Resource) bool {
+		return resource.ParentOwnerID != 0 && resource.ParentOwnerID == subject.UserID
+	}
+}
+
+// Role allows the subjects with one of the roles
+func Role(roles ...string) Rule {
+	return func(subject Subject, resource Resource) bool {
+		for _, role := range roles {
+			if subject.Role == role {
+				return true
+			}
+		}
+		return false
+	}
+}
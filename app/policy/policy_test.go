@@ -0,0 +1,108 @@
+package policy_test
+
+import (
+	"MyGramAPI/app/entity"
+	"MyGramAPI/app/policy"
+	"MyGramAPI/app/repository"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestDefaultPolicies(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemory()
+
+	// alice posted the photo, bob commented on it, eve has nothing to do with either
+	alice := policy.Subject{UserID: 1, Role: entity.RoleUser}
+	bob := policy.Subject{UserID: 2, Role: entity.RoleUser}
+	eve := policy.Subject{UserID: 3, Role: entity.RoleUser}
+	moderator := policy.Subject{UserID: 4, Role: entity.RoleModerator}
+	admin := policy.Subject{UserID: 5, Role: entity.RoleAdmin}
+
+	Photo := entity.Photo{Title: "title", Photo_URL: "http://example.com", UserID: alice.UserID}
+	if err := repos.Photos.Create(ctx, &Photo); err != nil {
+		t.Fatal(err)
+	}
+	Comment := entity.Comment{Message: "message", PhotoID: Photo.ID, UserID: bob.UserID}
+	if err := repos.Comments.Create(ctx, &Comment); err != nil {
+		t.Fatal(err)
+	}
+	SocialMedia := entity.SocialMedia{Name: "name", SocialMediaURL: "http://example.com", UserID: eve.UserID}
+	if err := repos.SocialMedia.Create(ctx, &SocialMedia); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		subject  policy.Subject
+		resource string
+		id       uint
+		action   policy.Action
+		allowed  bool
+	}{
+		{"owner updates the photo", alice, policy.ResourcePhoto, Photo.ID, policy.ActionUpdate, true},
+		{"owner deletes the photo", alice, policy.ResourcePhoto, Photo.ID, policy.ActionDelete, true},
+		{"other user updates the photo", eve, policy.ResourcePhoto, Photo.ID, policy.ActionUpdate, false},
+		{"other user deletes the photo", eve, policy.ResourcePhoto, Photo.ID, policy.ActionDelete, false},
+		{"moderator deletes the photo", moderator, policy.ResourcePhoto, Photo.ID, policy.ActionDelete, true},
+		{"moderator updates the photo", moderator, policy.ResourcePhoto, Photo.ID, policy.ActionUpdate, false},
+		{"admin deletes the photo", admin, policy.ResourcePhoto, Photo.ID, policy.ActionDelete, true},
+		{"admin updates the photo", admin, policy.ResourcePhoto, Photo.ID, policy.ActionUpdate, false},
+
+		{"owner updates the comment", bob, policy.ResourceComment, Comment.ID, policy.ActionUpdate, true},
+		{"owner deletes the comment", bob, policy.ResourceComment, Comment.ID, policy.ActionDelete, true},
+		{"photo owner deletes the comment", alice, policy.ResourceComment, Comment.ID, policy.ActionDelete, true},
+		{"photo owner updates the comment", alice, policy.ResourceComment, Comment.ID, policy.ActionUpdate, false},
+		{"other user deletes the comment", eve, policy.ResourceComment, Comment.ID, policy.ActionDelete, false},
+		{"moderator deletes the comment", moderator, policy.ResourceComment, Comment.ID, policy.ActionDelete, true},
+		{"admin deletes the comment", admin, policy.ResourceComment, Comment.ID, policy.ActionDelete, true},
+
+		{"owner updates the social media", eve, policy.ResourceSocialMedia, SocialMedia.ID, policy.ActionUpdate, true},
+		{"other user deletes the social media", alice, policy.ResourceSocialMedia, SocialMedia.ID, policy.ActionDelete, false},
+		{"moderator deletes the social media", moderator, policy.ResourceSocialMedia, SocialMedia.ID, policy.ActionDelete, false},
+		{"admin deletes the social media", admin, policy.ResourceSocialMedia, SocialMedia.ID, policy.ActionDelete, false},
+
+		{"owner does an unknown action", alice, policy.ResourcePhoto, Photo.ID, policy.Action("archive"), false},
+		{"admin does an unknown action", admin, policy.ResourcePhoto, Photo.ID, policy.Action("archive"), false},
+	}
+
+	engine := policy.Default(repos)
+	for _, test := range tests {
+		err := engine.Authorize(ctx, test.subject, test.resource, test.id, test.action)
+		switch {
+		case test.allowed && err != nil:
+			t.Errorf("%s: got %v, want it allowed", test.name, err)
+		case !test.allowed && !errors.Is(err, policy.ErrForbidden):
+			t.Errorf("%s: got %v, want ErrForbidden", test.name, err)
+		}
+	}
+}
+
+func TestAuthorizeErrors(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemory()
+	engine := policy.Default(repos)
+	admin := policy.Subject{UserID: 1, Role: entity.RoleAdmin}
+
+	// a missing record is reported as missing, not as forbidden
+	if err := engine.Authorize(ctx, admin, policy.ResourcePhoto, 99, policy.ActionDelete); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("missing photo: got %v, want ErrNotFound", err)
+	}
+
+	if err := engine.Authorize(ctx, admin, "album", 1, policy.ActionDelete); err == nil || errors.Is(err, policy.ErrForbidden) {
+		t.Errorf("unknown resource: got %v, want an error other than ErrForbidden", err)
+	}
+
+	// a comment left on a deleted photo can still be deleted by its owner but by no parent owner
+	Comment := entity.Comment{Message: "message", PhotoID: 99, UserID: 2}
+	if err := repos.Comments.Create(ctx, &Comment); err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.Authorize(ctx, policy.Subject{UserID: 2, Role: entity.RoleUser}, policy.ResourceComment, Comment.ID, policy.ActionDelete); err != nil {
+		t.Errorf("comment on a deleted photo: got %v, want it allowed", err)
+	}
+	if err := engine.Authorize(ctx, policy.Subject{Role: entity.RoleUser}, policy.ResourceComment, Comment.ID, policy.ActionDelete); !errors.Is(err, policy.ErrForbidden) {
+		t.Errorf("subject without id: got %v, want ErrForbidden", err)
+	}
+}
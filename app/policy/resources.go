@@ -0,0 +1,63 @@
+package policy
+
+import (
+	"MyGramAPI/app/entity"
+	"MyGramAPI/app/repository"
+	"context"
+	"errors"
+)
+
+// Types of resource of the default policies
+const (
+	ResourcePhoto       = "photo"
+	ResourceComment     = "comment"
+	ResourceSocialMedia = "socialMedia"
+)
+
+// Default returns the engine with the policies of the API: owners edit their own records, moderators and admins
+// can delete any photo or comment and the owner of a photo can delete the comments on it
+func Default(repos repository.Repositories) *Engine {
+	moderators := Role(entity.RoleModerator, entity.RoleAdmin)
+
+	engine := New()
+	engine.Register(ResourcePhoto, Policy{
+		Lookup: func(ctx context.Context, id uint) (Resource, error) {
+			Photo, err := repos.Photos.FindByID(ctx, id)
+			return Resource{OwnerID: Photo.UserID}, err
+		},
+		Rules: map[Action][]Rule{
+			ActionUpdate: {Owner()},
+			ActionDelete: {Owner(), moderators},
+		},
+	})
+	engine.Register(ResourceComment, Policy{
+		Lookup: func(ctx context.Context, id uint) (Resource, error) {
+			Comment, err := repos.Comments.FindByID(ctx, id)
+			if err != nil {
+				return Resource{}, err
+			}
+
+			// a comment left on a deleted photo has no parent owner
+			Photo, err := repos.Photos.FindByID(ctx, Comment.PhotoID)
+			if err != nil && !errors.Is(err, repository.ErrNotFound) {
+				return Resource{}, err
+			}
+			return Resource{OwnerID: Comment.UserID, ParentOwnerID: Photo.UserID}, nil
+		},
+		Rules: map[Action][]Rule{
+			ActionUpdate: {Owner()},
+			ActionDelete: {Owner(), ParentOwner(), moderators},
+		},
+	})
+	engine.Register(ResourceSocialMedia, Policy{
+		Lookup: func(ctx context.Context, id uint) (Resource, error) {
+			SocialMedia, err := repos.SocialMedia.FindByID(ctx, id)
+			return Resource{OwnerID: SocialMedia.UserID}, err
+		},
+		Rules: map[Action][]Rule{
+			ActionUpdate: {Owner()},
+			ActionDelete: {Owner()},
+		},
+	})
+	return engine
+}
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"MyGramAPI/app/entity"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type accessTokenRepository struct {
+	db *gorm.DB
+}
+
+func (r *accessTokenRepository) Create(ctx context.Context, token *entity.PersonalAccessToken) error {
+	return translate(r.db.WithContext(ctx).Create(token).Error)
+}
+
+func (r *accessTokenRepository) FindByHash(ctx context.Context, hash string) (entity.PersonalAccessToken, error) {
+	PersonalAccessToken := entity.PersonalAccessToken{}
+	err := r.db.WithContext(ctx).Where("token_hash = ?", hash).Take(&PersonalAccessToken).Error
+	return PersonalAccessToken, translate(err)
+}
+
+func (r *accessTokenRepository) FindByUserID(ctx context.Context, userID uint) ([]entity.PersonalAccessToken, error) {
+	PersonalAccessToken := []entity.PersonalAccessToken{}
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("id desc").Find(&PersonalAccessToken).Error
+	return PersonalAccessToken, translate(err)
+}
+
+func (r *accessTokenRepository) Delete(ctx context.Context, userID, id uint) error {
+	// the user_id condition keeps a user from revoking the tokens of someone else
+	return affected(r.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).Delete(&entity.PersonalAccessToken{}))
+}
+
+func (r *accessTokenRepository) Touch(ctx context.Context, id uint, at time.Time) error {
+	// UpdateColumn leaves updated_at alone, using a token doesn't change it
+	return translate(r.db.WithContext(ctx).Model(&entity.PersonalAccessToken{}).Where("id = ?", id).UpdateColumn("last_used_at", at).Error)
+}
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"MyGramAPI/app/entity"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type auditLogRepository struct {
+	db *gorm.DB
+}
+
+func (r *auditLogRepository) Create(ctx context.Context, log *entity.AuditLog) error {
+	return translate(r.db.WithContext(ctx).Create(log).Error)
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"MyGramAPI/app/entity"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type commentRepository struct {
+	db *gorm.DB
+}
+
+func (r *commentRepository) FindAll(ctx context.Context, page Page) ([]entity.Comment, int64, error) {
+	var total int64
+	if err := r.db.WithContext(ctx).Model(&entity.Comment{}).Count(&total).Error; err != nil {
+		return nil, 0, translate(err)
+	}
+
+	Comment := []entity.Comment{}
+	err := paginate(r.db.WithContext(ctx).Order("id"), page).Find(&Comment).Error
+	return Comment, total, translate(err)
+}
+
+func (r *commentRepository) FindByID(ctx context.Context, id uint) (entity.Comment, error) {
+	Comment := entity.Comment{}
+	err := r.db.WithContext(ctx).First(&Comment, id).Error
+	return Comment, translate(err)
+}
+
+func (r *commentRepository) FindByPhotoID(ctx context.Context, photoID uint) ([]entity.Comment, error) {
+	Comment := []entity.Comment{}
+	err := r.db.WithContext(ctx).Where("photo_id = ?", photoID).Find(&Comment).Error
+	return Comment, translate(err)
+}
+
+func (r *commentRepository) Create(ctx context.Context, comment *entity.Comment) error {
+	return translate(r.db.WithContext(ctx).Create(comment).Error)
+}
+
+func (r *commentRepository) Update(ctx context.Context, comment *entity.Comment) error {
+	result := r.db.WithContext(ctx).Model(comment).Where("id = ?", comment.ID).Updates(entity.Comment{Message: comment.Message})
+	return affected(result)
+}
+
+func (r *commentRepository) Delete(ctx context.Context, id uint) error {
+	result := r.db.WithContext(ctx).Where("id = ?", id).Delete(&entity.Comment{})
+	return affected(result)
+}
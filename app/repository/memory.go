@@ -0,0 +1,912 @@
+package repository
+
+import (
+	"MyGramAPI/app/entity"
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// touch fills the timestamps the database would otherwise set
+func touch(base *entity.Base, created bool) {
+	now := time.Now()
+	if created {
+		base.CreatedAt = &now
+	}
+	base.UpdatedAt = &now
+}
+
+type memoryUserRepository struct {
+	mu             sync.RWMutex
+	nextID         uint
+	users          map[uint]entity.User
+	nextIdentityID uint
+	// identities are keyed by their issuer and subject
+	identities map[[2]string]entity.LinkedIdentity
+}
+
+// NewMemoryUserRepository returns an in-memory UserRepository
+func NewMemoryUserRepository() UserRepository {
+	return &memoryUserRepository{users: map[uint]entity.User{}, identities: map[[2]string]entity.LinkedIdentity{}}
+}
+
+func (r *memoryUserRepository) Create(ctx context.Context, user *entity.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.taken(*user) {
+		return ErrConflict
+	}
+
+	if err := user.BeforeCreate(nil); err != nil {
+		return err
+	}
+
+	r.insert(user)
+	return nil
+}
+
+// taken reports whether the email or the username of the user is already used
+func (r *memoryUserRepository) taken(user entity.User) bool {
+	for _, existing := range r.users {
+		if existing.Email == user.Email || existing.Username == user.Username {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *memoryUserRepository) insert(user *entity.User) {
+	r.nextID++
+	user.ID = r.nextID
+	touch(&user.Base, true)
+	r.users[user.ID] = *user
+}
+
+func (r *memoryUserRepository) FindByID(ctx context.Context, id uint) (entity.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return entity.User{}, ErrNotFound
+	}
+	return user, nil
+}
+
+func (r *memoryUserRepository) FindByIDs(ctx context.Context, ids []uint) ([]entity.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	User := []entity.User{}
+	seen := map[uint]bool{}
+	for _, id := range ids {
+		if user, ok := r.users[id]; ok && !seen[id] {
+			User = append(User, user)
+			seen[id] = true
+		}
+	}
+	return User, nil
+}
+
+func (r *memoryUserRepository) FindByEmail(ctx context.Context, email string) (entity.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return entity.User{}, ErrNotFound
+}
+
+func (r *memoryUserRepository) UpdatePassword(ctx context.Context, id uint, hash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return ErrNotFound
+	}
+	user.Password = hash
+	touch(&user.Base, false)
+	r.users[id] = user
+	return nil
+}
+
+func (r *memoryUserRepository) MarkEmailVerified(ctx context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return ErrNotFound
+	}
+	user.EmailVerified = true
+	touch(&user.Base, false)
+	r.users[id] = user
+	return nil
+}
+
+func (r *memoryUserRepository) UpdateTOTP(ctx context.Context, id uint, secret string, enabled bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return ErrNotFound
+	}
+	user.TOTPSecret = secret
+	user.TOTPEnabled = enabled
+	touch(&user.Base, false)
+	r.users[id] = user
+	return nil
+}
+
+func (r *memoryUserRepository) UseTOTPStep(ctx context.Context, id uint, step int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok || user.TOTPLastStep >= step {
+		return ErrNotFound
+	}
+	user.TOTPLastStep = step
+	touch(&user.Base, false)
+	r.users[id] = user
+	return nil
+}
+
+func (r *memoryUserRepository) FindByIdentity(ctx context.Context, issuer, subject string) (entity.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	identity, ok := r.identities[[2]string{issuer, subject}]
+	if !ok {
+		return entity.User{}, ErrNotFound
+	}
+	return r.users[identity.UserID], nil
+}
+
+func (r *memoryUserRepository) CreateWithIdentity(ctx context.Context, user *entity.User, identity *entity.LinkedIdentity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.identities[[2]string{identity.Issuer, identity.Subject}]; ok || r.taken(*user) {
+		return ErrConflict
+	}
+
+	r.insert(user)
+	identity.UserID = user.ID
+	r.link(identity)
+	return nil
+}
+
+func (r *memoryUserRepository) LinkIdentity(ctx context.Context, identity *entity.LinkedIdentity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.identities[[2]string{identity.Issuer, identity.Subject}]; ok {
+		return ErrConflict
+	}
+	if _, ok := r.users[identity.UserID]; !ok {
+		return ErrNotFound
+	}
+
+	r.link(identity)
+	return nil
+}
+
+func (r *memoryUserRepository) FindAll(ctx context.Context, page Page) ([]entity.User, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	User := make([]entity.User, 0, len(r.users))
+	for _, user := range r.users {
+		User = append(User, user)
+	}
+
+	// oldest first, like the gorm implementation
+	sort.Slice(User, func(i, j int) bool { return User[i].ID < User[j].ID })
+	return slice(User, page), int64(len(User)), nil
+}
+
+func (r *memoryUserRepository) UpdateRole(ctx context.Context, id uint, role string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return ErrNotFound
+	}
+	user.Role = role
+	touch(&user.Base, false)
+	r.users[id] = user
+	return nil
+}
+
+func (r *memoryUserRepository) link(identity *entity.LinkedIdentity) {
+	r.nextIdentityID++
+	identity.ID = r.nextIdentityID
+	touch(&identity.Base, true)
+	r.identities[[2]string{identity.Issuer, identity.Subject}] = *identity
+}
+
+type memoryPhotoRepository struct {
+	mu     sync.RWMutex
+	nextID uint
+	photos map[uint]entity.Photo
+}
+
+// NewMemoryPhotoRepository returns an in-memory PhotoRepository
+func NewMemoryPhotoRepository() PhotoRepository {
+	return &memoryPhotoRepository{photos: map[uint]entity.Photo{}}
+}
+
+func (r *memoryPhotoRepository) FindAll(ctx context.Context, page Page) ([]entity.Photo, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	Photo := make([]entity.Photo, 0, len(r.photos))
+	for _, photo := range r.photos {
+		Photo = append(Photo, photo)
+	}
+
+	// newest first, like the gorm implementation
+	sort.Slice(Photo, func(i, j int) bool { return Photo[i].ID > Photo[j].ID })
+	return slice(Photo, page), int64(len(Photo)), nil
+}
+
+func (r *memoryPhotoRepository) FindByID(ctx context.Context, id uint) (entity.Photo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	photo, ok := r.photos[id]
+	if !ok {
+		return entity.Photo{}, ErrNotFound
+	}
+	return photo, nil
+}
+
+func (r *memoryPhotoRepository) Create(ctx context.Context, photo *entity.Photo) error {
+	if err := photo.BeforeCreate(nil); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	photo.ID = r.nextID
+	touch(&photo.Base, true)
+	r.photos[photo.ID] = *photo
+	return nil
+}
+
+func (r *memoryPhotoRepository) Update(ctx context.Context, photo *entity.Photo) error {
+	if err := photo.BeforeUpdate(nil); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.photos[photo.ID]
+	if !ok {
+		return ErrNotFound
+	}
+
+	// only non-zero fields are updated, the same as gorm's Updates
+	if photo.Title != "" {
+		existing.Title = photo.Title
+	}
+	if photo.Caption != "" {
+		existing.Caption = photo.Caption
+	}
+	if photo.Photo_URL != "" {
+		existing.Photo_URL = photo.Photo_URL
+	}
+	if photo.PhotoKey != "" {
+		existing.PhotoKey = photo.PhotoKey
+	}
+	touch(&existing.Base, false)
+	r.photos[photo.ID] = existing
+
+	photo.UpdatedAt = existing.UpdatedAt
+	return nil
+}
+
+func (r *memoryPhotoRepository) Delete(ctx context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.photos[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.photos, id)
+	return nil
+}
+
+type memoryCommentRepository struct {
+	mu       sync.RWMutex
+	nextID   uint
+	comments map[uint]entity.Comment
+}
+
+// NewMemoryCommentRepository returns an in-memory CommentRepository
+func NewMemoryCommentRepository() CommentRepository {
+	return &memoryCommentRepository{comments: map[uint]entity.Comment{}}
+}
+
+func (r *memoryCommentRepository) FindAll(ctx context.Context, page Page) ([]entity.Comment, int64, error) {
+	Comment := r.filter(func(entity.Comment) bool { return true })
+	return slice(Comment, page), int64(len(Comment)), nil
+}
+
+func (r *memoryCommentRepository) FindByID(ctx context.Context, id uint) (entity.Comment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	comment, ok := r.comments[id]
+	if !ok {
+		return entity.Comment{}, ErrNotFound
+	}
+	return comment, nil
+}
+
+func (r *memoryCommentRepository) FindByPhotoID(ctx context.Context, photoID uint) ([]entity.Comment, error) {
+	return r.filter(func(comment entity.Comment) bool { return comment.PhotoID == photoID }), nil
+}
+
+func (r *memoryCommentRepository) filter(keep func(entity.Comment) bool) []entity.Comment {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	Comment := []entity.Comment{}
+	for _, comment := range r.comments {
+		if keep(comment) {
+			Comment = append(Comment, comment)
+		}
+	}
+
+	sort.Slice(Comment, func(i, j int) bool { return Comment[i].ID < Comment[j].ID })
+	return Comment
+}
+
+func (r *memoryCommentRepository) Create(ctx context.Context, comment *entity.Comment) error {
+	if err := comment.BeforeCreate(nil); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	comment.ID = r.nextID
+	touch(&comment.Base, true)
+	r.comments[comment.ID] = *comment
+	return nil
+}
+
+func (r *memoryCommentRepository) Update(ctx context.Context, comment *entity.Comment) error {
+	if err := comment.BeforeUpdate(nil); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.comments[comment.ID]
+	if !ok {
+		return ErrNotFound
+	}
+
+	if comment.Message != "" {
+		existing.Message = comment.Message
+	}
+	touch(&existing.Base, false)
+	r.comments[comment.ID] = existing
+
+	comment.UpdatedAt = existing.UpdatedAt
+	return nil
+}
+
+func (r *memoryCommentRepository) Delete(ctx context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.comments[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.comments, id)
+	return nil
+}
+
+type memorySocialMediaRepository struct {
+	mu          sync.RWMutex
+	nextID      uint
+	socialMedia map[uint]entity.SocialMedia
+}
+
+// NewMemorySocialMediaRepository returns an in-memory SocialMediaRepository
+func NewMemorySocialMediaRepository() SocialMediaRepository {
+	return &memorySocialMediaRepository{socialMedia: map[uint]entity.SocialMedia{}}
+}
+
+func (r *memorySocialMediaRepository) FindAll(ctx context.Context, page Page) ([]entity.SocialMedia, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	SocialMedia := make([]entity.SocialMedia, 0, len(r.socialMedia))
+	for _, socialMedia := range r.socialMedia {
+		SocialMedia = append(SocialMedia, socialMedia)
+	}
+
+	sort.Slice(SocialMedia, func(i, j int) bool { return SocialMedia[i].ID < SocialMedia[j].ID })
+	return slice(SocialMedia, page), int64(len(SocialMedia)), nil
+}
+
+func (r *memorySocialMediaRepository) FindByID(ctx context.Context, id uint) (entity.SocialMedia, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	socialMedia, ok := r.socialMedia[id]
+	if !ok {
+		return entity.SocialMedia{}, ErrNotFound
+	}
+	return socialMedia, nil
+}
+
+func (r *memorySocialMediaRepository) Create(ctx context.Context, socialMedia *entity.SocialMedia) error {
+	if err := socialMedia.BeforeCreate(nil); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	socialMedia.ID = r.nextID
+	touch(&socialMedia.Base, true)
+	r.socialMedia[socialMedia.ID] = *socialMedia
+	return nil
+}
+
+func (r *memorySocialMediaRepository) Update(ctx context.Context, socialMedia *entity.SocialMedia) error {
+	if err := socialMedia.BeforeUpdate(nil); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.socialMedia[socialMedia.ID]
+	if !ok {
+		return ErrNotFound
+	}
+
+	if socialMedia.Name != "" {
+		existing.Name = socialMedia.Name
+	}
+	if socialMedia.SocialMediaURL != "" {
+		existing.SocialMediaURL = socialMedia.SocialMediaURL
+	}
+	touch(&existing.Base, false)
+	r.socialMedia[socialMedia.ID] = existing
+
+	socialMedia.UpdatedAt = existing.UpdatedAt
+	return nil
+}
+
+func (r *memorySocialMediaRepository) Delete(ctx context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.socialMedia[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.socialMedia, id)
+	return nil
+}
+
+type memoryRefreshTokenRepository struct {
+	mu     sync.Mutex
+	nextID uint
+	tokens map[uint]entity.RefreshToken
+}
+
+// NewMemoryRefreshTokenRepository returns an in-memory RefreshTokenRepository
+func NewMemoryRefreshTokenRepository() RefreshTokenRepository {
+	return &memoryRefreshTokenRepository{tokens: map[uint]entity.RefreshToken{}}
+}
+
+func (r *memoryRefreshTokenRepository) Create(ctx context.Context, token *entity.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	token.ID = r.nextID
+	touch(&token.Base, true)
+	r.tokens[token.ID] = *token
+	return nil
+}
+
+func (r *memoryRefreshTokenRepository) FindByHash(ctx context.Context, hash string) (entity.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, token := range r.tokens {
+		if token.TokenHash == hash {
+			return token, nil
+		}
+	}
+	return entity.RefreshToken{}, ErrNotFound
+}
+
+func (r *memoryRefreshTokenRepository) MarkUsed(ctx context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.tokens[id]
+	if !ok || token.UsedAt != nil {
+		return ErrConflict
+	}
+
+	now := time.Now()
+	token.UsedAt = &now
+	r.tokens[id] = token
+	return nil
+}
+
+func (r *memoryRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for id, token := range r.tokens {
+		if token.FamilyID == familyID && token.RevokedAt == nil {
+			token.RevokedAt = &now
+			r.tokens[id] = token
+		}
+	}
+	return nil
+}
+
+func (r *memoryRefreshTokenRepository) RevokeUser(ctx context.Context, userID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for id, token := range r.tokens {
+		if token.UserID == userID && token.RevokedAt == nil {
+			token.RevokedAt = &now
+			r.tokens[id] = token
+		}
+	}
+	return nil
+}
+
+type memoryUserTokenRepository struct {
+	mu     sync.Mutex
+	nextID uint
+	tokens map[uint]entity.UserToken
+}
+
+// NewMemoryUserTokenRepository returns an in-memory UserTokenRepository
+func NewMemoryUserTokenRepository() UserTokenRepository {
+	return &memoryUserTokenRepository{tokens: map[uint]entity.UserToken{}}
+}
+
+func (r *memoryUserTokenRepository) Create(ctx context.Context, token *entity.UserToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	token.ID = r.nextID
+	touch(&token.Base, true)
+	r.tokens[token.ID] = *token
+	return nil
+}
+
+func (r *memoryUserTokenRepository) FindByHash(ctx context.Context, purpose, hash string) (entity.UserToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, token := range r.tokens {
+		if token.Purpose == purpose && token.TokenHash == hash {
+			return token, nil
+		}
+	}
+	return entity.UserToken{}, ErrNotFound
+}
+
+func (r *memoryUserTokenRepository) MarkUsed(ctx context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.tokens[id]
+	if !ok || token.UsedAt != nil {
+		return ErrConflict
+	}
+
+	now := time.Now()
+	token.UsedAt = &now
+	r.tokens[id] = token
+	return nil
+}
+
+func (r *memoryUserTokenRepository) Discard(ctx context.Context, userID uint, purpose string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for id, token := range r.tokens {
+		if token.UserID == userID && token.Purpose == purpose && token.UsedAt == nil {
+			token.UsedAt = &now
+			r.tokens[id] = token
+		}
+	}
+	return nil
+}
+
+type memoryRecoveryCodeRepository struct {
+	mu     sync.Mutex
+	nextID uint
+	codes  map[uint]entity.RecoveryCode
+}
+
+// NewMemoryRecoveryCodeRepository returns an in-memory RecoveryCodeRepository
+func NewMemoryRecoveryCodeRepository() RecoveryCodeRepository {
+	return &memoryRecoveryCodeRepository{codes: map[uint]entity.RecoveryCode{}}
+}
+
+func (r *memoryRecoveryCodeRepository) Replace(ctx context.Context, userID uint, hashes []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, code := range r.codes {
+		if code.UserID == userID {
+			delete(r.codes, id)
+		}
+	}
+
+	for _, hash := range hashes {
+		r.nextID++
+		code := entity.RecoveryCode{UserID: userID, CodeHash: hash}
+		code.ID = r.nextID
+		touch(&code.Base, true)
+		r.codes[code.ID] = code
+	}
+	return nil
+}
+
+func (r *memoryRecoveryCodeRepository) Use(ctx context.Context, userID uint, hash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, code := range r.codes {
+		if code.UserID == userID && code.CodeHash == hash && code.UsedAt == nil {
+			now := time.Now()
+			code.UsedAt = &now
+			r.codes[id] = code
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (r *memoryRecoveryCodeRepository) Remaining(ctx context.Context, userID uint) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var remaining int64
+	for _, code := range r.codes {
+		if code.UserID == userID && code.UsedAt == nil {
+			remaining++
+		}
+	}
+	return remaining, nil
+}
+
+type memoryAuditLogRepository struct {
+	mu     sync.Mutex
+	nextID uint
+	logs   []entity.AuditLog
+}
+
+// NewMemoryAuditLogRepository returns an in-memory AuditLogRepository
+func NewMemoryAuditLogRepository() AuditLogRepository {
+	return &memoryAuditLogRepository{}
+}
+
+func (r *memoryAuditLogRepository) Create(ctx context.Context, log *entity.AuditLog) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	log.ID = r.nextID
+	touch(&log.Base, true)
+	r.logs = append(r.logs, *log)
+	return nil
+}
+
+type memoryAccessTokenRepository struct {
+	mu     sync.Mutex
+	nextID uint
+	tokens map[uint]entity.PersonalAccessToken
+}
+
+// NewMemoryAccessTokenRepository returns an in-memory PersonalAccessTokenRepository
+func NewMemoryAccessTokenRepository() PersonalAccessTokenRepository {
+	return &memoryAccessTokenRepository{tokens: map[uint]entity.PersonalAccessToken{}}
+}
+
+func (r *memoryAccessTokenRepository) Create(ctx context.Context, token *entity.PersonalAccessToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	token.ID = r.nextID
+	touch(&token.Base, true)
+	r.tokens[token.ID] = *token
+	return nil
+}
+
+func (r *memoryAccessTokenRepository) FindByHash(ctx context.Context, hash string) (entity.PersonalAccessToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, token := range r.tokens {
+		if token.TokenHash == hash {
+			return token, nil
+		}
+	}
+	return entity.PersonalAccessToken{}, ErrNotFound
+}
+
+func (r *memoryAccessTokenRepository) FindByUserID(ctx context.Context, userID uint) ([]entity.PersonalAccessToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	PersonalAccessToken := []entity.PersonalAccessToken{}
+	for _, token := range r.tokens {
+		if token.UserID == userID {
+			PersonalAccessToken = append(PersonalAccessToken, token)
+		}
+	}
+
+	// newest first, like the gorm implementation
+	sort.Slice(PersonalAccessToken, func(i, j int) bool { return PersonalAccessToken[i].ID > PersonalAccessToken[j].ID })
+	return PersonalAccessToken, nil
+}
+
+func (r *memoryAccessTokenRepository) Delete(ctx context.Context, userID, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.tokens[id]
+	if !ok || token.UserID != userID {
+		return ErrNotFound
+	}
+	delete(r.tokens, id)
+	return nil
+}
+
+func (r *memoryAccessTokenRepository) Touch(ctx context.Context, id uint, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.tokens[id]
+	if !ok {
+		return ErrNotFound
+	}
+	token.LastUsedAt = &at
+	r.tokens[id] = token
+	return nil
+}
+
+type memorySessionRepository struct {
+	mu       sync.Mutex
+	nextID   uint
+	sessions map[uint]entity.Session
+}
+
+// NewMemorySessionRepository returns an in-memory SessionRepository
+func NewMemorySessionRepository() SessionRepository {
+	return &memorySessionRepository{sessions: map[uint]entity.Session{}}
+}
+
+func (r *memorySessionRepository) Create(ctx context.Context, session *entity.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	session.ID = r.nextID
+	touch(&session.Base, true)
+	r.sessions[session.ID] = *session
+	return nil
+}
+
+func (r *memorySessionRepository) FindByID(ctx context.Context, id uint) (entity.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, ok := r.sessions[id]
+	if !ok {
+		return entity.Session{}, ErrNotFound
+	}
+	return session, nil
+}
+
+func (r *memorySessionRepository) FindByFamily(ctx context.Context, familyID string) (entity.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, session := range r.sessions {
+		if session.FamilyID == familyID {
+			return session, nil
+		}
+	}
+	return entity.Session{}, ErrNotFound
+}
+
+func (r *memorySessionRepository) FindByUserID(ctx context.Context, userID uint) ([]entity.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	Session := []entity.Session{}
+	for _, session := range r.sessions {
+		if session.UserID == userID {
+			Session = append(Session, session)
+		}
+	}
+
+	// last seen first, like the gorm implementation
+	sort.Slice(Session, func(i, j int) bool {
+		if !Session[i].LastSeenAt.Equal(Session[j].LastSeenAt) {
+			return Session[i].LastSeenAt.After(Session[j].LastSeenAt)
+		}
+		return Session[i].ID > Session[j].ID
+	})
+	return Session, nil
+}
+
+func (r *memorySessionRepository) Delete(ctx context.Context, userID, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, ok := r.sessions[id]
+	if !ok || session.UserID != userID {
+		return ErrNotFound
+	}
+	delete(r.sessions, id)
+	return nil
+}
+
+func (r *memorySessionRepository) DeleteUser(ctx context.Context, userID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, session := range r.sessions {
+		if session.UserID == userID {
+			delete(r.sessions, id)
+		}
+	}
+	return nil
+}
+
+func (r *memorySessionRepository) Touch(ctx context.Context, id uint, at time.Time, ip string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, ok := r.sessions[id]
+	if !ok {
+		return ErrNotFound
+	}
+	session.LastSeenAt = at
+	session.IP = ip
+	r.sessions[id] = session
+	return nil
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"MyGramAPI/app/entity"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type photoRepository struct {
+	db *gorm.DB
+}
+
+func (r *photoRepository) FindAll(ctx context.Context, page Page) ([]entity.Photo, int64, error) {
+	var total int64
+	if err := r.db.WithContext(ctx).Model(&entity.Photo{}).Count(&total).Error; err != nil {
+		return nil, 0, translate(err)
+	}
+
+	Photo := []entity.Photo{}
+	err := paginate(r.db.WithContext(ctx).Order("created_at desc, id desc"), page).Find(&Photo).Error
+	return Photo, total, translate(err)
+}
+
+func (r *photoRepository) FindByID(ctx context.Context, id uint) (entity.Photo, error) {
+	Photo := entity.Photo{}
+	err := r.db.WithContext(ctx).First(&Photo, id).Error
+	return Photo, translate(err)
+}
+
+func (r *photoRepository) Create(ctx context.Context, photo *entity.Photo) error {
+	return translate(r.db.WithContext(ctx).Create(photo).Error)
+}
+
+func (r *photoRepository) Update(ctx context.Context, photo *entity.Photo) error {
+	result := r.db.WithContext(ctx).Model(photo).Where("id = ?", photo.ID).Updates(entity.Photo{Title: photo.Title, Caption: photo.Caption, Photo_URL: photo.Photo_URL, PhotoKey: photo.PhotoKey})
+	return affected(result)
+}
+
+func (r *photoRepository) Delete(ctx context.Context, id uint) error {
+	result := r.db.WithContext(ctx).Where("id = ?", id).Delete(&entity.Photo{})
+	return affected(result)
+}
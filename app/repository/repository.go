@@ -0,0 +1,243 @@
+package repository
+
+import (
+	"MyGramAPI/app/entity"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrNotFound is returned when the requested record doesn't exist
+	ErrNotFound = errors.New("record not found")
+	// ErrConflict is returned when a record breaks a unique constraint, example: a username that is already taken
+	ErrConflict = errors.New("record already exists")
+	// ErrUnavailable is returned when the database can't be reached
+	ErrUnavailable = errors.New("database unavailable")
+)
+
+// Page selects a part of a list, a zero Limit returns the whole list
+type Page struct {
+	Limit  int
+	Offset int
+}
+
+// UserRepository persists the users
+type UserRepository interface {
+	Create(ctx context.Context, user *entity.User) error
+	FindByID(ctx context.Context, id uint) (entity.User, error)
+	FindByEmail(ctx context.Context, email string) (entity.User, error)
+	// FindByIDs returns the users among the ids in one query, once each, the missing ones are left out
+	FindByIDs(ctx context.Context, ids []uint) ([]entity.User, error)
+	// UpdatePassword stores an already hashed password
+	UpdatePassword(ctx context.Context, id uint, hash string) error
+	MarkEmailVerified(ctx context.Context, id uint) error
+	// UpdateTOTP stores the TOTP secret of the user, an empty secret disables two-factor authentication
+	UpdateTOTP(ctx context.Context, id uint, secret string, enabled bool) error
+	// UseTOTPStep records the time step of an accepted TOTP code, it returns ErrNotFound when the step isn't after
+	// the last used one, so a code is accepted once even by concurrent requests
+	UseTOTPStep(ctx context.Context, id uint, step int64) error
+	// FindByIdentity returns the user the account of the OpenID Connect provider is linked to
+	FindByIdentity(ctx context.Context, issuer, subject string) (entity.User, error)
+	// CreateWithIdentity creates the user of a first external login together with its identity.
+	// The user isn't validated like a registration since the provider doesn't share every field, its password must already be hashed.
+	CreateWithIdentity(ctx context.Context, user *entity.User, identity *entity.LinkedIdentity) error
+	// LinkIdentity links an identity to an existing user, it returns ErrConflict when the identity is already linked
+	LinkIdentity(ctx context.Context, identity *entity.LinkedIdentity) error
+	// FindAll returns a page of the users, oldest first, and the total count
+	FindAll(ctx context.Context, page Page) ([]entity.User, int64, error)
+	UpdateRole(ctx context.Context, id uint, role string) error
+}
+
+// PhotoRepository persists the photos
+type PhotoRepository interface {
+	FindAll(ctx context.Context, page Page) ([]entity.Photo, int64, error)
+	FindByID(ctx context.Context, id uint) (entity.Photo, error)
+	Create(ctx context.Context, photo *entity.Photo) error
+	Update(ctx context.Context, photo *entity.Photo) error
+	Delete(ctx context.Context, id uint) error
+}
+
+// CommentRepository persists the comments
+type CommentRepository interface {
+	FindAll(ctx context.Context, page Page) ([]entity.Comment, int64, error)
+	FindByID(ctx context.Context, id uint) (entity.Comment, error)
+	FindByPhotoID(ctx context.Context, photoID uint) ([]entity.Comment, error)
+	Create(ctx context.Context, comment *entity.Comment) error
+	Update(ctx context.Context, comment *entity.Comment) error
+	Delete(ctx context.Context, id uint) error
+}
+
+// SocialMediaRepository persists the social media
+type SocialMediaRepository interface {
+	FindAll(ctx context.Context, page Page) ([]entity.SocialMedia, int64, error)
+	FindByID(ctx context.Context, id uint) (entity.SocialMedia, error)
+	Create(ctx context.Context, socialMedia *entity.SocialMedia) error
+	Update(ctx context.Context, socialMedia *entity.SocialMedia) error
+	Delete(ctx context.Context, id uint) error
+}
+
+// RefreshTokenRepository persists the refresh tokens
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token *entity.RefreshToken) error
+	FindByHash(ctx context.Context, hash string) (entity.RefreshToken, error)
+	// MarkUsed returns ErrConflict when the token was already used
+	MarkUsed(ctx context.Context, id uint) error
+	RevokeFamily(ctx context.Context, familyID string) error
+	RevokeUser(ctx context.Context, userID uint) error
+}
+
+// UserTokenRepository persists the one-time tokens sent by mail
+type UserTokenRepository interface {
+	Create(ctx context.Context, token *entity.UserToken) error
+	FindByHash(ctx context.Context, purpose, hash string) (entity.UserToken, error)
+	// MarkUsed returns ErrConflict when the token was already used
+	MarkUsed(ctx context.Context, id uint) error
+	// Discard marks every unused token of the user for the purpose as used
+	Discard(ctx context.Context, userID uint, purpose string) error
+}
+
+// PersonalAccessTokenRepository persists the personal access tokens
+type PersonalAccessTokenRepository interface {
+	Create(ctx context.Context, token *entity.PersonalAccessToken) error
+	FindByHash(ctx context.Context, hash string) (entity.PersonalAccessToken, error)
+	// FindByUserID returns the tokens of the user, newest first
+	FindByUserID(ctx context.Context, userID uint) ([]entity.PersonalAccessToken, error)
+	// Delete returns ErrNotFound when the user has no token with the id
+	Delete(ctx context.Context, userID, id uint) error
+	// Touch sets when the token was last used
+	Touch(ctx context.Context, id uint, at time.Time) error
+}
+
+// SessionRepository persists the logins of the users
+type SessionRepository interface {
+	Create(ctx context.Context, session *entity.Session) error
+	FindByID(ctx context.Context, id uint) (entity.Session, error)
+	// FindByFamily returns the session of the refresh token family
+	FindByFamily(ctx context.Context, familyID string) (entity.Session, error)
+	// FindByUserID returns the sessions of the user, last seen first
+	FindByUserID(ctx context.Context, userID uint) ([]entity.Session, error)
+	// Delete returns ErrNotFound when the user has no session with the id
+	Delete(ctx context.Context, userID, id uint) error
+	DeleteUser(ctx context.Context, userID uint) error
+	// Touch sets when and from where the session was last seen
+	Touch(ctx context.Context, id uint, at time.Time, ip string) error
+}
+
+// RecoveryCodeRepository persists the two-factor recovery codes
+type RecoveryCodeRepository interface {
+	// Replace deletes the codes of the user and stores the new hashes
+	Replace(ctx context.Context, userID uint, hashes []string) error
+	// Use marks the code as used, it returns ErrNotFound when the user has no unused code with the hash
+	Use(ctx context.Context, userID uint, hash string) error
+	// Remaining counts the unused codes of the user
+	Remaining(ctx context.Context, userID uint) (int64, error)
+}
+
+// AuditLogRepository persists the audit logs, they are never updated
+type AuditLogRepository interface {
+	Create(ctx context.Context, log *entity.AuditLog) error
+}
+
+// Repositories groups every repository used by the services
+type Repositories struct {
+	Users         UserRepository
+	Photos        PhotoRepository
+	Comments      CommentRepository
+	SocialMedia   SocialMediaRepository
+	RefreshTokens RefreshTokenRepository
+	UserTokens    UserTokenRepository
+	RecoveryCodes RecoveryCodeRepository
+	AuditLogs     AuditLogRepository
+	AccessTokens  PersonalAccessTokenRepository
+	Sessions      SessionRepository
+}
+
+// NewGorm returns repositories backed by the given database handle
+func NewGorm(db *gorm.DB) Repositories {
+	return Repositories{
+		Users:         &userRepository{db: db},
+		Photos:        &photoRepository{db: db},
+		Comments:      &commentRepository{db: db},
+		SocialMedia:   &socialMediaRepository{db: db},
+		RefreshTokens: &refreshTokenRepository{db: db},
+		UserTokens:    &userTokenRepository{db: db},
+		RecoveryCodes: &recoveryCodeRepository{db: db},
+		AuditLogs:     &auditLogRepository{db: db},
+		AccessTokens:  &accessTokenRepository{db: db},
+		Sessions:      &sessionRepository{db: db},
+	}
+}
+
+// NewMemory returns repositories that keep everything in memory, useful for tests
+func NewMemory() Repositories {
+	return Repositories{
+		Users:         NewMemoryUserRepository(),
+		Photos:        NewMemoryPhotoRepository(),
+		Comments:      NewMemoryCommentRepository(),
+		SocialMedia:   NewMemorySocialMediaRepository(),
+		RefreshTokens: NewMemoryRefreshTokenRepository(),
+		UserTokens:    NewMemoryUserTokenRepository(),
+		RecoveryCodes: NewMemoryRecoveryCodeRepository(),
+		AuditLogs:     NewMemoryAuditLogRepository(),
+		AccessTokens:  NewMemoryAccessTokenRepository(),
+		Sessions:      NewMemorySessionRepository(),
+	}
+}
+
+// translate converts the errors of gorm and the database drivers into the errors of this package
+func translate(err error) error {
+	var netErr net.Error
+
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ErrNotFound
+	case errors.Is(err, gorm.ErrDuplicatedKey), strings.Contains(err.Error(), "UNIQUE constraint failed"):
+		// sqlite doesn't translate its errors, so its message is matched instead
+		return fmt.Errorf("%w: %v", ErrConflict, err)
+	case errors.Is(err, driver.ErrBadConn), errors.Is(err, sql.ErrConnDone), errors.As(err, &netErr):
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	return err
+}
+
+// paginate applies the page to the query
+func paginate(query *gorm.DB, page Page) *gorm.DB {
+	if page.Limit > 0 {
+		query = query.Limit(page.Limit)
+	}
+	return query.Offset(page.Offset)
+}
+
+// slice returns the part of a list selected by the page
+func slice[T any](list []T, page Page) []T {
+	if page.Offset < 0 || page.Offset >= len(list) {
+		return []T{}
+	}
+	list = list[page.Offset:]
+
+	if page.Limit > 0 && page.Limit < len(list) {
+		list = list[:page.Limit]
+	}
+	return list
+}
+
+// affected returns ErrNotFound when a write didn't touch any row
+func affected(result *gorm.DB) error {
+	if result.Error != nil {
+		return translate(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,275 @@
+package repository_test
+
+import (
+	"MyGramAPI/app/entity"
+	"MyGramAPI/app/repository"
+	"MyGramAPI/pkg/config"
+	"MyGramAPI/pkg/database"
+	"context"
+	"errors"
+	"math"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+// implementations returns a constructor of fresh repositories for every implementation, the gorm one runs on a
+// migrated SQLite database, so both are held to the same contract
+var implementations = map[string]func(t *testing.T) repository.Repositories{
+	"gorm": func(t *testing.T) repository.Repositories {
+		db, err := database.Connect(config.Database{DSN: "sqlite://" + filepath.Join(t.TempDir(), "test.db")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { database.Close(db) })
+
+		migrator, err := database.NewMigrator(db)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := migrator.Up(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		return repository.NewGorm(db)
+	},
+	"memory": func(t *testing.T) repository.Repositories {
+		return repository.NewMemory()
+	},
+}
+
+func TestRepositories(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, ctx context.Context, repos repository.Repositories)
+	}{
+		{"missing records are not found", testNotFound},
+		{"usernames and emails are unique", testConflict},
+		{"lists are paginated", testPagination},
+		{"users are found by ids at once", testFindByIDs},
+		{"a TOTP step is used once", testUseTOTPStep},
+		{"writes to missing records are not found", testMissingWrites},
+		{"records of another user are not found", testOtherUser},
+	}
+
+	for name, open := range implementations {
+		for _, test := range tests {
+			test := test
+			open := open
+			t.Run(name+"/"+test.name, func(t *testing.T) {
+				test.run(t, context.Background(), open(t))
+			})
+		}
+	}
+}
+
+func testNotFound(t *testing.T, ctx context.Context, repos repository.Repositories) {
+	lookups := map[string]func() error{
+		"user by id":    func() error { _, err := repos.Users.FindByID(ctx, 99); return err },
+		"user by email": func() error { _, err := repos.Users.FindByEmail(ctx, "nobody@example.com"); return err },
+		"photo":         func() error { _, err := repos.Photos.FindByID(ctx, 99); return err },
+		"comment":       func() error { _, err := repos.Comments.FindByID(ctx, 99); return err },
+		"social media":  func() error { _, err := repos.SocialMedia.FindByID(ctx, 99); return err },
+		"session":       func() error { _, err := repos.Sessions.FindByID(ctx, 99); return err },
+		"access token":  func() error { _, err := repos.AccessTokens.FindByHash(ctx, "missing"); return err },
+		"refresh token": func() error { _, err := repos.RefreshTokens.FindByHash(ctx, "missing"); return err },
+	}
+
+	for name, lookup := range lookups {
+		if err := lookup(); !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("%s: got %v, want ErrNotFound", name, err)
+		}
+	}
+}
+
+func testConflict(t *testing.T, ctx context.Context, repos repository.Repositories) {
+	createUser(t, ctx, repos, "alice")
+
+	tests := []struct {
+		name string
+		user entity.User
+	}{
+		{"same email", entity.User{Username: "bob", Email: "alice@example.com", Password: "secret123", Age: 20}},
+		{"same username", entity.User{Username: "alice", Email: "bob@example.com", Password: "secret123", Age: 20}},
+	}
+	for _, test := range tests {
+		if err := repos.Users.Create(ctx, &test.user); !errors.Is(err, repository.ErrConflict) {
+			t.Errorf("%s: got %v, want ErrConflict", test.name, err)
+		}
+	}
+}
+
+func testPagination(t *testing.T, ctx context.Context, repos repository.Repositories) {
+	User := createUser(t, ctx, repos, "alice")
+
+	ids := []uint{}
+	for _, title := range []string{"first", "second", "third"} {
+		Photo := entity.Photo{Title: title, Photo_URL: "http://example.com/" + title, UserID: User.ID}
+		if err := repos.Photos.Create(ctx, &Photo); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, Photo.ID)
+	}
+
+	tests := []struct {
+		page repository.Page
+		want []uint
+	}{
+		{repository.Page{}, []uint{ids[2], ids[1], ids[0]}},
+		{repository.Page{Limit: 2}, []uint{ids[2], ids[1]}},
+		{repository.Page{Limit: 2, Offset: 1}, []uint{ids[1], ids[0]}},
+		{repository.Page{Limit: 2, Offset: 3}, []uint{}},
+		{repository.Page{Limit: 2, Offset: math.MaxInt - 1}, []uint{}},
+	}
+	for _, test := range tests {
+		Photo, total, err := repos.Photos.FindAll(ctx, test.page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if total != 3 {
+			t.Errorf("page %+v: got a total of %d, want 3", test.page, total)
+		}
+
+		got := []uint{}
+		for _, photo := range Photo {
+			got = append(got, photo.ID)
+		}
+		if !equal(got, test.want) {
+			t.Errorf("page %+v: got photos %v, want %v", test.page, got, test.want)
+		}
+	}
+}
+
+func testFindByIDs(t *testing.T, ctx context.Context, repos repository.Repositories) {
+	Alice := createUser(t, ctx, repos, "alice")
+	Bob := createUser(t, ctx, repos, "bob")
+	createUser(t, ctx, repos, "eve")
+
+	User, err := repos.Users.FindByIDs(ctx, []uint{Bob.ID, 99, Alice.ID, Bob.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []uint{}
+	for _, user := range User {
+		got = append(got, user.ID)
+	}
+	sort.Slice(got, func(i, j int) bool { return got[i] < got[j] })
+	if !equal(got, []uint{Alice.ID, Bob.ID}) {
+		t.Errorf("got users %v, want %v", got, []uint{Alice.ID, Bob.ID})
+	}
+
+	if User, err := repos.Users.FindByIDs(ctx, nil); err != nil || len(User) != 0 {
+		t.Errorf("no ids: got %v and %v, want no users", User, err)
+	}
+}
+
+func testUseTOTPStep(t *testing.T, ctx context.Context, repos repository.Repositories) {
+	User := createUser(t, ctx, repos, "alice")
+
+	steps := []struct {
+		step int64
+		err  error
+	}{
+		{100, nil},
+		{100, repository.ErrNotFound},
+		{99, repository.ErrNotFound},
+		{101, nil},
+	}
+	for _, test := range steps {
+		if err := repos.Users.UseTOTPStep(ctx, User.ID, test.step); !errors.Is(err, test.err) {
+			t.Errorf("step %d: got %v, want %v", test.step, err, test.err)
+		}
+	}
+
+	User, err := repos.Users.FindByID(ctx, User.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if User.TOTPLastStep != 101 {
+		t.Errorf("got last step %d, want 101", User.TOTPLastStep)
+	}
+	if err := repos.Users.UseTOTPStep(ctx, 99, 200); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("missing user: got %v, want ErrNotFound", err)
+	}
+}
+
+func testMissingWrites(t *testing.T, ctx context.Context, repos repository.Repositories) {
+	User := createUser(t, ctx, repos, "alice")
+
+	writes := map[string]func() error{
+		"update photo": func() error {
+			return repos.Photos.Update(ctx, &entity.Photo{Base: entity.Base{ID: 99}, Title: "title", Photo_URL: "http://example.com"})
+		},
+		"delete photo": func() error { return repos.Photos.Delete(ctx, 99) },
+		"update comment": func() error {
+			return repos.Comments.Update(ctx, &entity.Comment{Base: entity.Base{ID: 99}, Message: "message"})
+		},
+		"delete comment": func() error { return repos.Comments.Delete(ctx, 99) },
+		"update social media": func() error {
+			return repos.SocialMedia.Update(ctx, &entity.SocialMedia{Base: entity.Base{ID: 99}, Name: "name", SocialMediaURL: "http://example.com"})
+		},
+		"delete social media": func() error { return repos.SocialMedia.Delete(ctx, 99) },
+		"update password":     func() error { return repos.Users.UpdatePassword(ctx, 99, "hash") },
+		"update role":         func() error { return repos.Users.UpdateRole(ctx, 99, entity.RoleAdmin) },
+		"delete session":      func() error { return repos.Sessions.Delete(ctx, User.ID, 99) },
+		"delete access token": func() error { return repos.AccessTokens.Delete(ctx, User.ID, 99) },
+	}
+
+	for name, write := range writes {
+		if err := write(); !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("%s: got %v, want ErrNotFound", name, err)
+		}
+	}
+}
+
+func testOtherUser(t *testing.T, ctx context.Context, repos repository.Repositories) {
+	Alice := createUser(t, ctx, repos, "alice")
+	Bob := createUser(t, ctx, repos, "bob")
+
+	Session := entity.Session{UserID: Alice.ID, FamilyID: "family", LastSeenAt: time.Now()}
+	if err := repos.Sessions.Create(ctx, &Session); err != nil {
+		t.Fatal(err)
+	}
+	PersonalAccessToken := entity.PersonalAccessToken{UserID: Alice.ID, Name: "script", Scopes: entity.ScopePhotosWrite, TokenHash: "hash"}
+	if err := repos.AccessTokens.Create(ctx, &PersonalAccessToken); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repos.Sessions.Delete(ctx, Bob.ID, Session.ID); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("delete session: got %v, want ErrNotFound", err)
+	}
+	if err := repos.AccessTokens.Delete(ctx, Bob.ID, PersonalAccessToken.ID); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("delete access token: got %v, want ErrNotFound", err)
+	}
+
+	// the owner still can
+	if err := repos.Sessions.Delete(ctx, Alice.ID, Session.ID); err != nil {
+		t.Errorf("delete own session: %v", err)
+	}
+	if err := repos.AccessTokens.Delete(ctx, Alice.ID, PersonalAccessToken.ID); err != nil {
+		t.Errorf("delete own access token: %v", err)
+	}
+}
+
+// createUser creates a valid user with the username, its email is the username at example.com
+func createUser(t *testing.T, ctx context.Context, repos repository.Repositories, username string) entity.User {
+	t.Helper()
+
+	User := entity.User{Username: username, Email: username + "@example.com", Password: "secret123", Age: 20}
+	if err := repos.Users.Create(ctx, &User); err != nil {
+		t.Fatal(err)
+	}
+	return User
+}
+
+func equal(a, b []uint) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"MyGramAPI/app/entity"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+func (r *sessionRepository) Create(ctx context.Context, session *entity.Session) error {
+	return translate(r.db.WithContext(ctx).Create(session).Error)
+}
+
+func (r *sessionRepository) FindByID(ctx context.Context, id uint) (entity.Session, error) {
+	Session := entity.Session{}
+	err := r.db.WithContext(ctx).First(&Session, id).Error
+	return Session, translate(err)
+}
+
+func (r *sessionRepository) FindByFamily(ctx context.Context, familyID string) (entity.Session, error) {
+	Session := entity.Session{}
+	err := r.db.WithContext(ctx).Where("family_id = ?", familyID).Take(&Session).Error
+	return Session, translate(err)
+}
+
+func (r *sessionRepository) FindByUserID(ctx context.Context, userID uint) ([]entity.Session, error) {
+	Session := []entity.Session{}
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("last_seen_at desc, id desc").Find(&Session).Error
+	return Session, translate(err)
+}
+
+func (r *sessionRepository) Delete(ctx context.Context, userID, id uint) error {
+	// the user_id condition keeps a user from removing the sessions of someone else
+	return affected(r.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).Delete(&entity.Session{}))
+}
+
+func (r *sessionRepository) DeleteUser(ctx context.Context, userID uint) error {
+	return translate(r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&entity.Session{}).Error)
+}
+
+func (r *sessionRepository) Touch(ctx context.Context, id uint, at time.Time, ip string) error {
+	// UpdateColumns leaves updated_at alone, like the last use of the access tokens
+	result := r.db.WithContext(ctx).Model(&entity.Session{}).Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{"last_seen_at": at, "ip": ip})
+	return translate(result.Error)
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"MyGramAPI/app/entity"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type socialMediaRepository struct {
+	db *gorm.DB
+}
+
+func (r *socialMediaRepository) FindAll(ctx context.Context, page Page) ([]entity.SocialMedia, int64, error) {
+	var total int64
+	if err := r.db.WithContext(ctx).Model(&entity.SocialMedia{}).Count(&total).Error; err != nil {
+		return nil, 0, translate(err)
+	}
+
+	SocialMedia := []entity.SocialMedia{}
+	err := paginate(r.db.WithContext(ctx).Order("id"), page).Find(&SocialMedia).Error
+	return SocialMedia, total, translate(err)
+}
+
+func (r *socialMediaRepository) FindByID(ctx context.Context, id uint) (entity.SocialMedia, error) {
+	SocialMedia := entity.SocialMedia{}
+	err := r.db.WithContext(ctx).First(&SocialMedia, id).Error
+	return SocialMedia, translate(err)
+}
+
+func (r *socialMediaRepository) Create(ctx context.Context, socialMedia *entity.SocialMedia) error {
+	return translate(r.db.WithContext(ctx).Create(socialMedia).Error)
+}
+
+func (r *socialMediaRepository) Update(ctx context.Context, socialMedia *entity.SocialMedia) error {
+	result := r.db.WithContext(ctx).Model(socialMedia).Where("id = ?", socialMedia.ID).Updates(entity.SocialMedia{Name: socialMedia.Name, SocialMediaURL: socialMedia.SocialMediaURL})
+	return affected(result)
+}
+
+func (r *socialMediaRepository) Delete(ctx context.Context, id uint) error {
+	result := r.db.WithContext(ctx).Where("id = ?", id).Delete(&entity.SocialMedia{})
+	return affected(result)
+}
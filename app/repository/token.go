@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"MyGramAPI/app/entity"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type refreshTokenRepository struct {
+	db *gorm.DB
+}
+
+func (r *refreshTokenRepository) Create(ctx context.Context, token *entity.RefreshToken) error {
+	return translate(r.db.WithContext(ctx).Create(token).Error)
+}
+
+func (r *refreshTokenRepository) FindByHash(ctx context.Context, hash string) (entity.RefreshToken, error) {
+	RefreshToken := entity.RefreshToken{}
+	err := r.db.WithContext(ctx).Where("token_hash = ?", hash).Take(&RefreshToken).Error
+	return RefreshToken, translate(err)
+}
+
+func (r *refreshTokenRepository) MarkUsed(ctx context.Context, id uint) error {
+	// the used_at condition makes the update the lock, only one of two concurrent refreshes wins
+	result := r.db.WithContext(ctx).Model(&entity.RefreshToken{}).Where("id = ? AND used_at IS NULL", id).Update("used_at", time.Now())
+	if result.Error != nil {
+		return translate(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+func (r *refreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	result := r.db.WithContext(ctx).Model(&entity.RefreshToken{}).Where("family_id = ? AND revoked_at IS NULL", familyID).Update("revoked_at", time.Now())
+	return translate(result.Error)
+}
+
+func (r *refreshTokenRepository) RevokeUser(ctx context.Context, userID uint) error {
+	result := r.db.WithContext(ctx).Model(&entity.RefreshToken{}).Where("user_id = ? AND revoked_at IS NULL", userID).Update("revoked_at", time.Now())
+	return translate(result.Error)
+}
+
+type userTokenRepository struct {
+	db *gorm.DB
+}
+
+func (r *userTokenRepository) Create(ctx context.Context, token *entity.UserToken) error {
+	return translate(r.db.WithContext(ctx).Create(token).Error)
+}
+
+func (r *userTokenRepository) FindByHash(ctx context.Context, purpose, hash string) (entity.UserToken, error) {
+	UserToken := entity.UserToken{}
+	err := r.db.WithContext(ctx).Where("purpose = ? AND token_hash = ?", purpose, hash).Take(&UserToken).Error
+	return UserToken, translate(err)
+}
+
+func (r *userTokenRepository) MarkUsed(ctx context.Context, id uint) error {
+	// the same conditional update as the refresh tokens, a token can't be used by two concurrent requests
+	result := r.db.WithContext(ctx).Model(&entity.UserToken{}).Where("id = ? AND used_at IS NULL", id).Update("used_at", time.Now())
+	if result.Error != nil {
+		return translate(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+func (r *userTokenRepository) Discard(ctx context.Context, userID uint, purpose string) error {
+	result := r.db.WithContext(ctx).Model(&entity.UserToken{}).Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).Update("used_at", time.Now())
+	return translate(result.Error)
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"MyGramAPI/app/entity"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type recoveryCodeRepository struct {
+	db *gorm.DB
+}
+
+func (r *recoveryCodeRepository) Replace(ctx context.Context, userID uint, hashes []string) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&entity.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if len(hashes) == 0 {
+			return nil
+		}
+
+		RecoveryCode := make([]entity.RecoveryCode, len(hashes))
+		for i, hash := range hashes {
+			RecoveryCode[i] = entity.RecoveryCode{UserID: userID, CodeHash: hash}
+		}
+		return tx.Create(&RecoveryCode).Error
+	})
+	return translate(err)
+}
+
+func (r *recoveryCodeRepository) Use(ctx context.Context, userID uint, hash string) error {
+	// the used_at condition keeps a code from being used by two concurrent logins
+	result := r.db.WithContext(ctx).Model(&entity.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", time.Now())
+	return affected(result)
+}
+
+func (r *recoveryCodeRepository) Remaining(ctx context.Context, userID uint) (int64, error) {
+	var remaining int64
+	err := r.db.WithContext(ctx).Model(&entity.RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userID).Count(&remaining).Error
+	return remaining, translate(err)
+}
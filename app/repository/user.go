@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"MyGramAPI/app/entity"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type userRepository struct {
+	db *gorm.DB
+}
+
+func (r *userRepository) Create(ctx context.Context, user *entity.User) error {
+	return translate(r.db.WithContext(ctx).Create(user).Error)
+}
+
+func (r *userRepository) FindByID(ctx context.Context, id uint) (entity.User, error) {
+	User := entity.User{}
+	err := r.db.WithContext(ctx).First(&User, id).Error
+	return User, translate(err)
+}
+
+func (r *userRepository) FindByIDs(ctx context.Context, ids []uint) ([]entity.User, error) {
+	User := []entity.User{}
+	if len(ids) == 0 {
+		return User, nil
+	}
+	err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&User).Error
+	return User, translate(err)
+}
+
+func (r *userRepository) FindByEmail(ctx context.Context, email string) (entity.User, error) {
+	User := entity.User{}
+	err := r.db.WithContext(ctx).Where("email = ?", email).Take(&User).Error
+	return User, translate(err)
+}
+
+func (r *userRepository) UpdatePassword(ctx context.Context, id uint, hash string) error {
+	return affected(r.db.WithContext(ctx).Model(&entity.User{}).Where("id = ?", id).Update("password", hash))
+}
+
+func (r *userRepository) MarkEmailVerified(ctx context.Context, id uint) error {
+	return affected(r.db.WithContext(ctx).Model(&entity.User{}).Where("id = ?", id).Update("email_verified", true))
+}
+
+func (r *userRepository) UpdateTOTP(ctx context.Context, id uint, secret string, enabled bool) error {
+	result := r.db.WithContext(ctx).Model(&entity.User{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"totp_secret": secret, "totp_enabled": enabled})
+	return affected(result)
+}
+
+func (r *userRepository) UseTOTPStep(ctx context.Context, id uint, step int64) error {
+	return affected(r.db.WithContext(ctx).Model(&entity.User{}).Where("id = ? AND totp_last_step < ?", id, step).Update("totp_last_step", step))
+}
+
+func (r *userRepository) FindByIdentity(ctx context.Context, issuer, subject string) (entity.User, error) {
+	User := entity.User{}
+	err := r.db.WithContext(ctx).
+		Joins("JOIN linked_identities ON linked_identities.user_id = users.id").
+		Where("linked_identities.issuer = ? AND linked_identities.subject = ?", issuer, subject).
+		Take(&User).Error
+	return User, translate(err)
+}
+
+func (r *userRepository) CreateWithIdentity(ctx context.Context, user *entity.User, identity *entity.LinkedIdentity) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// the hooks would validate the user like a registration and hash the password again
+		if err := tx.Session(&gorm.Session{SkipHooks: true}).Create(user).Error; err != nil {
+			return err
+		}
+
+		identity.UserID = user.ID
+		return tx.Create(identity).Error
+	})
+	return translate(err)
+}
+
+func (r *userRepository) LinkIdentity(ctx context.Context, identity *entity.LinkedIdentity) error {
+	return translate(r.db.WithContext(ctx).Create(identity).Error)
+}
+
+func (r *userRepository) FindAll(ctx context.Context, page Page) ([]entity.User, int64, error) {
+	var total int64
+	if err := r.db.WithContext(ctx).Model(&entity.User{}).Count(&total).Error; err != nil {
+		return nil, 0, translate(err)
+	}
+
+	User := []entity.User{}
+	err := paginate(r.db.WithContext(ctx).Order("id"), page).Find(&User).Error
+	return User, total, translate(err)
+}
+
+func (r *userRepository) UpdateRole(ctx context.Context, id uint, role string) error {
+	return affected(r.db.WithContext(ctx).Model(&entity.User{}).Where("id = ?", id).Update("role", role))
+}
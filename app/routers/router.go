@@ -1,69 +1,122 @@
-package routers
-
-import (
-	"MyGramAPI/app/middleware"
-	"MyGramAPI/app/services"
-
-	_ "MyGramAPI/docs"
-
-	"github.com/gin-contrib/cors"
-	"github.com/gin-gonic/gin"
-	swaggerFiles "github.com/swaggo/files"
-	ginSwagger "github.com/swaggo/gin-swagger"
-)
-
-func StartServer() *gin.Engine {
-	router := gin.Default()
-	config := cors.DefaultConfig()
-	config.AllowOrigins = []string{"*"}
-	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
-	config.AllowHeaders = []string{"Authorization", "Content-Type"}
-
-	router.Use(cors.New(config))
-	router.Use(cors.Default())
-
-	router.MaxMultipartMemory = 10 << 20 // 10 MB
-	router.GET("swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-
-	v1 := router.Group("/api/v1")
-	{
-		userRouter := v1.Group("/users")
-		{
-			userRouter.POST("/register", services.UserRegister)
-			userRouter.POST("/login", services.UserLogin)
-		}
-
-		photoRouter := v1.Group("/photos")
-		{
-			photoRouter.GET("/", services.GetAllPhoto)
-			photoRouter.GET("/:id", services.GetPhoto)
-			photoRouter.Use(middleware.Authentication())
-			photoRouter.POST("/", services.CreatePhoto)
-			photoRouter.PUT("/:id", middleware.Authorization("photo"), services.UpdatePhoto)
-			photoRouter.DELETE("/:id", middleware.Authorization("photo"), services.DeletePhoto)
-		}
-
-		commentRouter := v1.Group("/comments")
-		{
-			commentRouter.GET("/", services.GetAllComment)
-			commentRouter.GET("/:id", services.GetComment)
-			commentRouter.Use(middleware.Authentication())
-			commentRouter.POST("/", services.CreateComment)
-			commentRouter.PUT("/:id", middleware.Authorization("comment"), services.UpdateComment)
-			commentRouter.DELETE("/:id", middleware.Authorization("comment"), services.DeleteComment)
-		}
-
-		socialMediaRouter := v1.Group("/social-media")
-		{
-			socialMediaRouter.GET("/", services.GetAllSocialMedia)
-			socialMediaRouter.GET("/:id", services.GetSocialMedia)
-			socialMediaRouter.Use(middleware.Authentication())
-			socialMediaRouter.POST("/", services.CreateSocialMedia)
-			socialMediaRouter.PUT("/:id", middleware.Authorization("socialMedia"), services.UpdateSocialMedia)
-			socialMediaRouter.DELETE("/:id", middleware.Authorization("socialMedia"), services.DeleteSocialMedia)
-		}
-	}
-
-	router.Run(":8082")
-	return router
-}
+package routers
+
+import (
+	"MyGramAPI/app/entity"
+	"MyGramAPI/app/middleware"
+	"MyGramAPI/app/policy"
+	"MyGramAPI/app/repository"
+	"MyGramAPI/app/services"
+	"MyGramAPI/pkg/attempts"
+	"MyGramAPI/pkg/config"
+	"MyGramAPI/pkg/mail"
+	"MyGramAPI/pkg/revocation"
+	"MyGramAPI/pkg/storage"
+
+	_ "MyGramAPI/docs"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+	"gorm.io/gorm"
+)
+
+// NewRouter registers every route, the engine is served by Serve
+func NewRouter(db *gorm.DB, media storage.MediaStore, revocations revocation.Store, loginAttempts attempts.Store, mailer mail.Mailer, cfg config.Config) *gin.Engine {
+	repos := repository.NewGorm(db)
+	service := services.New(repos, media, revocations, loginAttempts, mailer, cfg)
+	authentication := middleware.Authentication(revocations, repos.AccessTokens, repos.Sessions)
+	// the read routes also take the personal access tokens, every scope includes read-only
+	read := middleware.Authentication(revocations, repos.AccessTokens, repos.Sessions, entity.ScopeReadOnly)
+	verifiedEmail := middleware.VerifiedEmail(repos.Users, cfg.Auth.RequireVerifiedEmail)
+	policies := policy.Default(repos)
+	authorize := func(resource string, action policy.Action) gin.HandlerFunc {
+		return middleware.Authorization(repos.Users, policies, resource, action)
+	}
+
+	router := gin.Default()
+	config := cors.DefaultConfig()
+	config.AllowOrigins = []string{"*"}
+	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
+	config.AllowHeaders = []string{"Authorization", "Content-Type"}
+
+	router.Use(cors.New(config))
+	router.Use(cors.Default())
+	router.Use(middleware.Errors())
+
+	router.MaxMultipartMemory = 10 << 20 // 10 MB
+	router.GET("swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	if cfg.Storage.Driver == "local" {
+		// the other drivers serve the media from their own URLs
+		router.GET("/media/*key", service.GetMedia)
+	}
+	router.GET("/.well-known/jwks.json", service.GetJWKS)
+
+	v1 := router.Group("/api/v1")
+	{
+		userRouter := v1.Group("/users")
+		{
+			userRouter.POST("/register", service.UserRegister)
+			userRouter.POST("/login", service.UserLogin)
+			userRouter.POST("/login/2fa", service.UserLoginChallenge)
+			userRouter.POST("/unlock", service.UserUnlock)
+			userRouter.GET("/oidc/login", service.UserOIDCLogin)
+			userRouter.GET("/oidc/callback", service.UserOIDCCallback)
+			userRouter.POST("/refresh", service.UserRefresh)
+			userRouter.POST("/logout", authentication, service.UserLogout)
+			userRouter.POST("/logout-all", authentication, service.UserLogoutAll)
+			userRouter.POST("/password-reset", service.UserRequestPasswordReset)
+			userRouter.POST("/password-reset/confirm", service.UserConfirmPasswordReset)
+			userRouter.POST("/verify-email", service.UserVerifyEmail)
+			userRouter.POST("/verify-email/resend", authentication, service.UserResendVerification)
+			userRouter.POST("/2fa/enroll", authentication, service.UserEnrollTOTP)
+			userRouter.POST("/2fa/confirm", authentication, service.UserConfirmTOTP)
+			userRouter.POST("/2fa/recovery-codes", authentication, service.UserRegenerateRecoveryCodes)
+			userRouter.POST("/2fa/disable", authentication, service.UserDisableTOTP)
+			userRouter.GET("/me/tokens", read, service.UserListAccessTokens)
+			userRouter.POST("/me/tokens", authentication, service.UserCreateAccessToken)
+			userRouter.DELETE("/me/tokens/:id", authentication, service.UserRevokeAccessToken)
+			userRouter.GET("/me/sessions", read, service.UserListSessions)
+			userRouter.DELETE("/me/sessions/:id", authentication, service.UserRemoveSession)
+		}
+
+		photoRouter := v1.Group("/photos")
+		{
+			photoRouter.GET("/", service.GetAllPhoto)
+			photoRouter.GET("/:id", service.GetPhoto)
+			photoRouter.Use(middleware.Authentication(revocations, repos.AccessTokens, repos.Sessions, entity.ScopePhotosWrite))
+			photoRouter.POST("/", verifiedEmail, service.CreatePhoto)
+			photoRouter.PUT("/:id", authorize(policy.ResourcePhoto, policy.ActionUpdate), service.UpdatePhoto)
+			photoRouter.DELETE("/:id", authorize(policy.ResourcePhoto, policy.ActionDelete), service.DeletePhoto)
+		}
+
+		commentRouter := v1.Group("/comments")
+		{
+			commentRouter.GET("/", service.GetAllComment)
+			commentRouter.GET("/:id", service.GetComment)
+			commentRouter.Use(middleware.Authentication(revocations, repos.AccessTokens, repos.Sessions, entity.ScopeCommentsWrite))
+			commentRouter.POST("/", verifiedEmail, service.CreateComment)
+			commentRouter.PUT("/:id", authorize(policy.ResourceComment, policy.ActionUpdate), service.UpdateComment)
+			commentRouter.DELETE("/:id", authorize(policy.ResourceComment, policy.ActionDelete), service.DeleteComment)
+		}
+
+		socialMediaRouter := v1.Group("/social-media")
+		{
+			socialMediaRouter.GET("/", service.GetAllSocialMedia)
+			socialMediaRouter.GET("/:id", service.GetSocialMedia)
+			socialMediaRouter.Use(authentication)
+			socialMediaRouter.POST("/", service.CreateSocialMedia)
+			socialMediaRouter.PUT("/:id", authorize(policy.ResourceSocialMedia, policy.ActionUpdate), service.UpdateSocialMedia)
+			socialMediaRouter.DELETE("/:id", authorize(policy.ResourceSocialMedia, policy.ActionDelete), service.DeleteSocialMedia)
+		}
+
+		adminRouter := v1.Group("/admin")
+		{
+			adminRouter.Use(authentication, middleware.RequireRole(repos.Users, entity.RoleAdmin))
+			adminRouter.GET("/users", service.AdminListUsers)
+			adminRouter.PUT("/users/:id/role", service.AdminUpdateRole)
+		}
+	}
+
+	return router
+}
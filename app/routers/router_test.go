@@ -0,0 +1,98 @@
+package routers
+
+import (
+	"MyGramAPI/app/entity"
+	"MyGramAPI/app/repository"
+	"MyGramAPI/pkg/attempts"
+	"MyGramAPI/pkg/config"
+	"MyGramAPI/pkg/database"
+	"MyGramAPI/pkg/helpers"
+	"MyGramAPI/pkg/mail"
+	"MyGramAPI/pkg/revocation"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	netmail "net/mail"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestReadOnlyAccessToken checks that a read-only token is accepted on the read routes that need a login and nowhere else
+func TestReadOnlyAccessToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+
+	db, err := database.Connect(config.Database{DSN: "sqlite://" + filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close(db)
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.Default()
+	mailer, err := mail.NewFile(t.TempDir(), &netmail.Address{Address: "no-reply@mygram.example"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := NewRouter(db, nil, revocation.NewMemory(), attempts.NewMemory(), mailer, cfg)
+
+	repos := repository.NewGorm(db)
+	User := entity.User{Username: "alice", Email: "alice@example.com", Password: "secret123", Age: 20}
+	if err := repos.Users.Create(ctx, &User); err != nil {
+		t.Fatal(err)
+	}
+	Photo := entity.Photo{Title: "title", Photo_URL: "http://example.com", UserID: User.ID}
+	if err := repos.Photos.Create(ctx, &Photo); err != nil {
+		t.Fatal(err)
+	}
+
+	tokens := map[string]string{}
+	for _, scope := range []string{entity.ScopeReadOnly, entity.ScopePhotosWrite} {
+		token := entity.AccessTokenPrefix + scope
+		PersonalAccessToken := entity.PersonalAccessToken{UserID: User.ID, Name: scope, Scopes: scope, TokenHash: helpers.HashToken(token)}
+		if err := repos.AccessTokens.Create(ctx, &PersonalAccessToken); err != nil {
+			t.Fatal(err)
+		}
+		tokens[scope] = token
+	}
+
+	tests := []struct {
+		scope  string
+		method string
+		target string
+		status int
+	}{
+		{entity.ScopeReadOnly, http.MethodGet, "/api/v1/users/me/tokens", http.StatusOK},
+		{entity.ScopeReadOnly, http.MethodGet, "/api/v1/users/me/sessions", http.StatusOK},
+		{entity.ScopeReadOnly, http.MethodPost, "/api/v1/photos/", http.StatusForbidden},
+		{entity.ScopeReadOnly, http.MethodPut, "/api/v1/photos/1", http.StatusForbidden},
+		{entity.ScopeReadOnly, http.MethodDelete, "/api/v1/photos/1", http.StatusForbidden},
+		{entity.ScopeReadOnly, http.MethodPost, "/api/v1/comments/", http.StatusForbidden},
+		{entity.ScopeReadOnly, http.MethodPut, "/api/v1/comments/1", http.StatusForbidden},
+		{entity.ScopeReadOnly, http.MethodDelete, "/api/v1/comments/1", http.StatusForbidden},
+		{entity.ScopeReadOnly, http.MethodPost, "/api/v1/users/me/tokens", http.StatusForbidden},
+		{entity.ScopeReadOnly, http.MethodDelete, "/api/v1/users/me/sessions/1", http.StatusForbidden},
+		// every scope includes read-only
+		{entity.ScopePhotosWrite, http.MethodGet, "/api/v1/users/me/sessions", http.StatusOK},
+		{entity.ScopePhotosWrite, http.MethodDelete, "/api/v1/photos/1", http.StatusOK},
+	}
+
+	for _, test := range tests {
+		request := httptest.NewRequest(test.method, test.target, nil)
+		request.Header.Set("Authorization", "Bearer "+tokens[test.scope])
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+
+		if recorder.Code != test.status {
+			t.Errorf("%s token on %s %s: got status %d, want %d: %s", test.scope, test.method, test.target, recorder.Code, test.status, recorder.Body)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package routers
+
+import (
+	"MyGramAPI/pkg/config"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Serve listens on the configured address until ctx is done, then stops accepting
+// connections and waits for the in-flight requests, like uploads, to finish
+func Serve(ctx context.Context, cfg config.Server, handler http.Handler) error {
+	server := &http.Server{
+		Addr:         cfg.Addr,
+		Handler:      handler,
+		ReadTimeout:  time.Duration(cfg.ReadTimeout),
+		WriteTimeout: time.Duration(cfg.WriteTimeout),
+		IdleTimeout:  time.Duration(cfg.IdleTimeout),
+	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Printf("listening on %s", cfg.Addr)
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Println("shutting down, waiting for the in-flight requests")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.ShutdownTimeout))
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+
+	if err := <-serverErr; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"MyGramAPI/app/apperror"
+	"MyGramAPI/app/entity"
+	"MyGramAPI/pkg/helpers"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UserCreateAccessToken godoc
+// @Summary Create a personal access token
+// @Description Create a token for scripts, sent as "Bearer mgp_..." like the login token. It can only be used on the routes of its scopes: photos:write (upload, edit and delete photos), comments:write (post, edit and delete comments) or read-only (list your tokens and sessions). The token is only shown in this response, it never expires without expires_at
+// @Tags users
+// @Consumes ({mpfd,json})
+// @Produce json
+// @Param name formData string true "name of the token, to recognize it later"
+// @Param scopes formData []string true "scopes of the token: photos:write, comments:write or read-only" collectionFormat(multi)
+// @Param expires_at formData string false "when the token expires, RFC 3339, example: 2030-01-01T00:00:00Z"
+// @Success 201 {object} entity.Response "The token, save it now since it's not shown again"
+// @Failure 400  {object}  entity.Response "If some fields are invalid, every invalid field is listed in error.details"
+// @Failure 401  {object}  entity.Response "If you are not logged in"
+// @Failure 403  {object}  entity.Response "If the request is made with a personal access token"
+// @Security Bearer
+// @Router /api/v1/users/me/tokens [post]
+func (s *Service) UserCreateAccessToken(c *gin.Context) {
+	userData := c.MustGet("userData").(*helpers.Claims)
+	contentType := helpers.GetContentType(c)
+	Request := entity.AccessTokenRequest{}
+
+	var err error
+	if contentType == appJSON {
+		err = c.ShouldBindJSON(&Request)
+	} else {
+		err = c.ShouldBind(&Request)
+	}
+	// a malformed expires_at can't be bound, it would otherwise look like a missing one
+	if err != nil {
+		c.Error(apperror.Validation("Invalid request body", fieldErrors(err)))
+		return
+	}
+
+	fields := validate(&Request)
+	if len(Request.Scopes) == 0 {
+		fields = append(fields, entity.FieldError{Field: "scopes", Code: "required", Message: "At least one scope is required"})
+	}
+	for _, scope := range Request.Scopes {
+		if !validScope(scope) {
+			fields = append(fields, entity.FieldError{Field: "scopes", Code: "in", Message: "Scope " + scope + " is not one of " + strings.Join(entity.AccessTokenScopes, ", ")})
+		}
+	}
+	if Request.ExpiresAt != nil && !Request.ExpiresAt.After(time.Now()) {
+		fields = append(fields, entity.FieldError{Field: "expires_at", Code: "future", Message: "Expiry must be in the future"})
+	}
+	if len(fields) > 0 {
+		c.Error(validationError(fields))
+		return
+	}
+
+	random, _, err := helpers.RandomToken()
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	// the prefix is part of the token, so it's hashed with it
+	token := entity.AccessTokenPrefix + random
+
+	PersonalAccessToken := entity.PersonalAccessToken{
+		UserID:    userData.UserID(),
+		Name:      Request.Name,
+		Scopes:    strings.Join(Request.Scopes, " "),
+		TokenHash: helpers.HashToken(token),
+		ExpiresAt: Request.ExpiresAt,
+	}
+
+	if err := s.AccessTokens.Create(c.Request.Context(), &PersonalAccessToken); err != nil {
+		c.Error(repositoryError(err, "Access token"))
+		return
+	}
+
+	DataAccessToken := accessTokenData(PersonalAccessToken)
+	DataAccessToken.Token = token
+
+	c.JSON(http.StatusCreated, entity.Response{
+		Success: true,
+		Message: "Access token has been created, save it now since it won't be shown again",
+		Data:    DataAccessToken,
+	})
+}
+
+// UserListAccessTokens godoc
+// @Summary List the personal access tokens
+// @Description List the personal access tokens of the user, newest first, with their scopes, expiry and last use. The tokens themselves are never shown again
+// @Tags users
+// @Produce json
+// @Success 200 {object} entity.Response "The tokens of the user"
+// @Failure 401  {object}  entity.Response "If you are not logged in"
+// @Failure 403  {object}  entity.Response "If the request is made with a personal access token"
+// @Security Bearer
+// @Router /api/v1/users/me/tokens [get]
+func (s *Service) UserListAccessTokens(c *gin.Context) {
+	userData := c.MustGet("userData").(*helpers.Claims)
+
+	PersonalAccessToken, err := s.AccessTokens.FindByUserID(c.Request.Context(), userData.UserID())
+	if err != nil {
+		c.Error(repositoryError(err, "Access token"))
+		return
+	}
+
+	ResData := []entity.DataAccessToken{}
+	for _, token := range PersonalAccessToken {
+		ResData = append(ResData, accessTokenData(token))
+	}
+
+	c.JSON(http.StatusOK, entity.Response{
+		Success: true,
+		Message: "Access tokens has been loaded successfully",
+		Data:    ResData,
+	})
+}
+
+// UserRevokeAccessToken godoc
+// @Summary Revoke a personal access token
+// @Description Delete a personal access token of the user, it's rejected right away
+// @Tags users
+// @Produce json
+// @Param id path int true "access token id"
+// @Success 200 {object} entity.Response "If the token is revoked"
+// @Failure 401  {object}  entity.Response "If you are not logged in"
+// @Failure 403  {object}  entity.Response "If the request is made with a personal access token"
+// @Failure 404  {object}  entity.Response "If you have no token with the id"
+// @Security Bearer
+// @Router /api/v1/users/me/tokens/{id} [delete]
+func (s *Service) UserRevokeAccessToken(c *gin.Context) {
+	userData := c.MustGet("userData").(*helpers.Claims)
+	tokenID, _ := strconv.Atoi(c.Param("id"))
+
+	if err := s.AccessTokens.Delete(c.Request.Context(), userData.UserID(), uint(tokenID)); err != nil {
+		c.Error(repositoryError(err, "Access token"))
+		return
+	}
+
+	c.JSON(http.StatusOK, entity.Response{
+		Success: true,
+		Message: "Access token has been revoked",
+		Data:    nil,
+	})
+}
+
+// accessTokenData describes the token without its secret
+func accessTokenData(token entity.PersonalAccessToken) entity.DataAccessToken {
+	return entity.DataAccessToken{
+		ID:         token.ID,
+		Name:       token.Name,
+		Scopes:     strings.Fields(token.Scopes),
+		ExpiresAt:  token.ExpiresAt,
+		LastUsedAt: token.LastUsedAt,
+		CreatedAt:  token.CreatedAt,
+	}
+}
+
+func validScope(scope string) bool {
+	for _, valid := range entity.AccessTokenScopes {
+		if scope == valid {
+			return true
+		}
+	}
+	return false
+}
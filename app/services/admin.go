@@ -0,0 +1,120 @@
+package services
+
+import (
+	"MyGramAPI/app/apperror"
+	"MyGramAPI/app/entity"
+	"MyGramAPI/pkg/helpers"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminListUsers godoc
+// @Summary List the users
+// @Description Admins can list every user with their role, oldest first
+// @Tags admin
+// @Produce json
+// @Param page query int false "page number, starts at 1"
+// @Param per_page query int false "items per page, 20 by default and 100 at most"
+// @Success 200 {object} entity.Response "The users"
+// @Failure 401  {object}  entity.Response "If you are not logged in"
+// @Failure 403  {object}  entity.Response "If you are not an admin or the request is made with a personal access token"
+// @Security Bearer
+// @Router /api/v1/admin/users [get]
+func (s *Service) AdminListUsers(c *gin.Context) {
+	page, perPage := pageFromQuery(c)
+	User, total, err := s.Users.FindAll(c.Request.Context(), repositoryPage(page, perPage))
+	if err != nil {
+		c.Error(repositoryError(err, "User"))
+		return
+	}
+
+	ResData := []entity.DataUser{}
+	for _, user := range User {
+		ResData = append(ResData, adminUserData(user))
+	}
+
+	c.JSON(http.StatusOK, entity.Response{
+		Success: true,
+		Message: "Users has been loaded successfully",
+		Data:    ResData,
+		Meta:    pagination(page, perPage, total),
+	})
+}
+
+// AdminUpdateRole godoc
+// @Summary Change the role of a user
+// @Description Admins can make a user a moderator, an admin or a normal user again. Admins can't change their own role, so there is always an admin left
+// @Tags admin
+// @Consumes ({mpfd,json})
+// @Produce json
+// @Param id path int true "user id"
+// @Param role formData string true "the new role: user, moderator or admin"
+// @Success 200 {object} entity.Response "The user with the new role"
+// @Failure 400  {object}  entity.Response "If the role is invalid or it's your own account"
+// @Failure 401  {object}  entity.Response "If you are not logged in"
+// @Failure 403  {object}  entity.Response "If you are not an admin or the request is made with a personal access token"
+// @Failure 404  {object}  entity.Response "If the user doesn't exist"
+// @Security Bearer
+// @Router /api/v1/admin/users/{id}/role [put]
+func (s *Service) AdminUpdateRole(c *gin.Context) {
+	ctx := c.Request.Context()
+	userData := c.MustGet("userData").(*helpers.Claims)
+	adminID := userData.UserID()
+	targetID, _ := strconv.Atoi(c.Param("id"))
+	contentType := helpers.GetContentType(c)
+	Request := entity.RoleRequest{}
+
+	if contentType == appJSON {
+		c.ShouldBindJSON(&Request)
+	} else {
+		c.ShouldBind(&Request)
+	}
+
+	fields := validate(&Request)
+	if Request.Role != "" && !entity.ValidRole(Request.Role) {
+		fields = append(fields, entity.FieldError{Field: "role", Code: "in", Message: "Role " + Request.Role + " is not one of " + strings.Join(entity.Roles, ", ")})
+	}
+	if len(fields) > 0 {
+		c.Error(validationError(fields))
+		return
+	}
+
+	if uint(targetID) == adminID {
+		c.Error(apperror.Validation("You can't change your own role", nil))
+		return
+	}
+
+	if err := s.Users.UpdateRole(ctx, uint(targetID), Request.Role); err != nil {
+		c.Error(repositoryError(err, "User"))
+		return
+	}
+
+	User, err := s.Users.FindByID(ctx, uint(targetID))
+	if err != nil {
+		c.Error(repositoryError(err, "User"))
+		return
+	}
+	s.audit(ctx, entity.AuditRoleChanged, &User.ID, c.ClientIP(), "role set to "+User.Role+" by user "+strconv.Itoa(int(adminID)))
+
+	c.JSON(http.StatusOK, entity.Response{
+		Success: true,
+		Message: "Role has been updated successfully",
+		Data:    adminUserData(User),
+	})
+}
+
+// adminUserData describes the user without its secrets
+func adminUserData(user entity.User) entity.DataUser {
+	return entity.DataUser{
+		ID:            user.ID,
+		Username:      user.Username,
+		Email:         user.Email,
+		Age:           user.Age,
+		Role:          user.Role,
+		EmailVerified: user.EmailVerified,
+		CreatedAt:     user.CreatedAt,
+	}
+}
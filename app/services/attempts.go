@@ -0,0 +1,156 @@
+package services
+
+import (
+	"MyGramAPI/app/apperror"
+	"MyGramAPI/app/entity"
+	"MyGramAPI/pkg/helpers"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UserUnlock godoc
+// @Summary Unlock the account
+// @Description Unlock an account locked out after too many failed logins, with the token of the link mailed on the lockout. A token works once
+// @Tags users
+// @Consumes ({mpfd,json})
+// @Produce json
+// @Param token formData string true "token of the unlock link"
+// @Success 200 {object} entity.Response "If the token is valid, the account can log in again"
+// @Failure 400  {object}  entity.Response "If the token is invalid, expired or already used"
+// @Router /api/v1/users/unlock [post]
+func (s *Service) UserUnlock(c *gin.Context) {
+	ctx := c.Request.Context()
+	contentType := helpers.GetContentType(c)
+	Unlock := entity.AccountUnlock{}
+
+	if contentType == appJSON {
+		c.ShouldBindJSON(&Unlock)
+	} else {
+		c.ShouldBind(&Unlock)
+	}
+
+	if fields := validate(&Unlock); len(fields) > 0 {
+		c.Error(validationError(fields))
+		return
+	}
+
+	UserToken, err := s.useUserToken(ctx, entity.PurposeAccountUnlock, Unlock.Token, apperror.Validation("Unlock token is invalid or has expired", nil))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	User, err := s.Users.FindByID(ctx, UserToken.UserID)
+	if err != nil {
+		c.Error(repositoryError(err, "User"))
+		return
+	}
+
+	if err := s.LoginAttempts.Reset(ctx, accountKey(User.Email)); err != nil {
+		c.Error(apperror.Unavailable("Login attempt store is unavailable, please try again later", err))
+		return
+	}
+	s.audit(ctx, entity.AuditAccountUnlocked, &User.ID, c.ClientIP(), "unlocked with the mailed link")
+
+	c.JSON(http.StatusOK, entity.Response{
+		Success: true,
+		Message: "Account has been unlocked, you can log in again",
+		Data:    nil,
+	})
+}
+
+// checkLoginAttempts rejects a login while the account or the IP address has to wait after its failed logins
+func (s *Service) checkLoginAttempts(ctx context.Context, email, ip string) error {
+	now := time.Now()
+
+	account, err := s.LoginAttempts.Get(ctx, accountKey(email))
+	if err != nil {
+		return apperror.Unavailable("Login attempt store is unavailable, please try again later", err)
+	}
+	if wait, locked := s.AccountPolicy.Wait(account, now); locked {
+		return apperror.TooManyRequests("Too many failed logins, the account is locked. Check your email to unlock it or try again later", wait)
+	} else if wait > 0 {
+		return apperror.TooManyRequests("Too many failed logins, please wait before trying again", wait)
+	}
+
+	address, err := s.LoginAttempts.Get(ctx, ipKey(ip))
+	if err != nil {
+		return apperror.Unavailable("Login attempt store is unavailable, please try again later", err)
+	}
+	if wait, locked := s.IPPolicy.Wait(address, now); locked {
+		return apperror.TooManyRequests("Too many failed logins from your network, please try again later", wait)
+	} else if wait > 0 {
+		return apperror.TooManyRequests("Too many failed logins, please wait before trying again", wait)
+	}
+	return nil
+}
+
+// loginFailed counts a failed login of the account and the IP address, user is nil when the email isn't registered.
+// The failure that locks an account out mails its unlock link, both lockouts are audited.
+func (s *Service) loginFailed(ctx context.Context, email, ip string, user *entity.User) {
+	now := time.Now()
+
+	account, err := s.LoginAttempts.Fail(ctx, accountKey(email), now, s.AccountPolicy.Since(now))
+	if err != nil {
+		log.Printf("error counting the failed login of %s: %v", email, err)
+	} else if account.Failures == s.AccountPolicy.LockoutAttempts {
+		var userID *uint
+		if user != nil {
+			userID = &user.ID
+		}
+		s.audit(ctx, entity.AuditAccountLocked, userID, ip, fmt.Sprintf("%d failed logins for %s", account.Failures, email))
+
+		if user != nil {
+			if err := s.sendUnlock(ctx, *user); err != nil {
+				log.Printf("error sending the unlock mail of user %d: %v", user.ID, err)
+			}
+		}
+	}
+
+	address, err := s.LoginAttempts.Fail(ctx, ipKey(ip), now, s.IPPolicy.Since(now))
+	if err != nil {
+		log.Printf("error counting the failed login from %s: %v", ip, err)
+	} else if address.Failures == s.IPPolicy.LockoutAttempts {
+		s.audit(ctx, entity.AuditIPLocked, nil, ip, fmt.Sprintf("%d failed logins", address.Failures))
+	}
+}
+
+// loginSucceeded forgets the failed logins of the account, the IP address keeps its count so one valid account can't reset it
+func (s *Service) loginSucceeded(ctx context.Context, email string) {
+	if err := s.LoginAttempts.Reset(ctx, accountKey(email)); err != nil {
+		log.Printf("error resetting the failed logins of %s: %v", email, err)
+	}
+}
+
+// sendUnlock mails a new unlock link to the user
+func (s *Service) sendUnlock(ctx context.Context, user entity.User) error {
+	return s.mailToken(ctx, user, entity.PurposeAccountUnlock, s.AccountPolicy.LockoutDuration, s.UnlockURL,
+		"Your MyGram account has been locked",
+		"Hi %s,\n\n"+
+			"Your MyGram account has been locked after too many failed logins. "+
+			"If it was you, use this within %s to unlock it:\n\n%s\n\n"+
+			"If it wasn't you, someone may be guessing your password, consider resetting it.\n")
+}
+
+// audit records a security event, a failure is logged since the event itself already happened
+func (s *Service) audit(ctx context.Context, action string, userID *uint, ip, details string) {
+	AuditLog := entity.AuditLog{UserID: userID, Action: action, IP: ip, Details: details}
+	if err := s.AuditLogs.Create(ctx, &AuditLog); err != nil {
+		log.Printf("error recording audit log %s: %v", action, err)
+	}
+}
+
+// accountKey and ipKey name the failed login counters, the email is normalized so its case can't bypass the count
+func accountKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"MyGramAPI/app/entity"
+	"MyGramAPI/app/repository"
+	"MyGramAPI/pkg/config"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	netmail "net/mail"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUserLoginLockoutMailsAnUnlockLink(t *testing.T) {
+	cfg := testConfig()
+	cfg.Auth.AccountUnlockURL = "https://mygram.example/unlock"
+	cfg.Auth.AccountFreeAttempts = 2
+	cfg.Auth.AccountLockoutAttempts = 3
+	// no backoff between the failures, so the test reaches the lockout at once
+	cfg.Auth.LoginBackoff = config.Duration(time.Nanosecond)
+	cfg.Auth.LoginMaxBackoff = config.Duration(time.Nanosecond)
+	s, dir := newTestService(t, cfg)
+	audits := &recordingAuditLogs{AuditLogRepository: s.AuditLogs}
+	s.AuditLogs = audits
+	Alice := createUser(t, s, "alice")
+
+	for i := 0; i < 3; i++ {
+		if recorder := loginWith(s, "alice@example.com", "wrong"); recorder.Code != http.StatusUnauthorized {
+			t.Fatalf("failure %d: got status %d, want 401: %s", i, recorder.Code, recorder.Body)
+		}
+	}
+	checkAudit(t, audits, entity.AuditAccountLocked, &Alice.ID)
+
+	// even the right password waits until the lockout is over
+	recorder := loginWith(s, "alice@example.com", "secret123")
+	if recorder.Code != http.StatusTooManyRequests {
+		t.Fatalf("login of the locked account: got status %d, want 429: %s", recorder.Code, recorder.Body)
+	}
+	if retryAfter, _ := strconv.Atoi(recorder.Header().Get("Retry-After")); retryAfter < 3590 || retryAfter > 3600 {
+		t.Errorf("got Retry-After %q, want the hour of the lockout", recorder.Header().Get("Retry-After"))
+	}
+
+	// the mailed link unlocks the account at once
+	token := mailedToken(t, dir, cfg.Auth.AccountUnlockURL)
+	recorder = serve("/api/v1/users/unlock", jsonRequest(http.MethodPost, "/api/v1/users/unlock", `{"token":"`+token+`"}`), s.UserUnlock)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("unlock: got status %d, want 200: %s", recorder.Code, recorder.Body)
+	}
+	checkAudit(t, audits, entity.AuditAccountUnlocked, &Alice.ID)
+
+	if recorder := loginWith(s, "alice@example.com", "secret123"); recorder.Code != http.StatusOK {
+		t.Fatalf("login after the unlock: got status %d, want 200: %s", recorder.Code, recorder.Body)
+	}
+
+	// a link works once
+	recorder = serve("/api/v1/users/unlock", jsonRequest(http.MethodPost, "/api/v1/users/unlock", `{"token":"`+token+`"}`), s.UserUnlock)
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("second unlock: got status %d, want 400: %s", recorder.Code, recorder.Body)
+	}
+}
+
+func TestUserLoginResetsTheAccountCount(t *testing.T) {
+	s, _ := newTestService(t, testConfig())
+	createUser(t, s, "alice")
+
+	for i := 0; i < 2; i++ {
+		if recorder := loginWith(s, "alice@example.com", "wrong"); recorder.Code != http.StatusUnauthorized {
+			t.Fatalf("failure %d: got status %d, want 401: %s", i, recorder.Code, recorder.Body)
+		}
+	}
+	checkFailures(t, s, accountKey("alice@example.com"), 2)
+
+	if recorder := loginWith(s, "Alice@Example.com ", "wrong"); recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("failure with another case: got status %d, want 401: %s", recorder.Code, recorder.Body)
+	}
+	checkFailures(t, s, accountKey("alice@example.com"), 3)
+
+	// the free attempts are used up, the next login waits for the backoff
+	recorder := loginWith(s, "alice@example.com", "secret123")
+	if recorder.Code != http.StatusTooManyRequests {
+		t.Fatalf("login during the backoff: got status %d, want 429: %s", recorder.Code, recorder.Body)
+	}
+	if retryAfter := recorder.Header().Get("Retry-After"); retryAfter != "1" {
+		t.Errorf("got Retry-After %q, want the second of the first backoff", retryAfter)
+	}
+	time.Sleep(time.Second)
+
+	// a login forgets the failures of the account, not the ones of the network
+	if recorder := loginWith(s, "alice@example.com", "secret123"); recorder.Code != http.StatusOK {
+		t.Fatalf("login after the backoff: got status %d, want 200: %s", recorder.Code, recorder.Body)
+	}
+	checkFailures(t, s, accountKey("alice@example.com"), 0)
+	checkFailures(t, s, ipKey("203.0.113.7"), 3)
+}
+
+// loginWith sends a login with the email and the password
+func loginWith(s *Service, email, password string) *httptest.ResponseRecorder {
+	request := jsonRequest(http.MethodPost, "/api/v1/users/login", `{"email":"`+email+`","password":"`+password+`"}`)
+	return serve("/api/v1/users/login", request, s.UserLogin)
+}
+
+// mailedToken returns the token of the link to the page in the last mail written to the directory
+func mailedToken(t *testing.T, dir, page string) string {
+	t.Helper()
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no mail was sent")
+	}
+
+	file, err := os.Open(filepath.Join(dir, files[len(files)-1].Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	message, err := netmail.ReadMessage(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(message.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := strings.Index(string(body), page)
+	if start < 0 {
+		t.Fatalf("the mail has no link to %s:\n%s", page, body)
+	}
+	link, err := url.Parse(strings.Fields(string(body[start:]))[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return link.Query().Get("token")
+}
+
+// recordingAuditLogs keeps the audit logs it stores
+type recordingAuditLogs struct {
+	repository.AuditLogRepository
+	logs []entity.AuditLog
+}
+
+func (r *recordingAuditLogs) Create(ctx context.Context, log *entity.AuditLog) error {
+	if err := r.AuditLogRepository.Create(ctx, log); err != nil {
+		return err
+	}
+	r.logs = append(r.logs, *log)
+	return nil
+}
+
+// checkAudit checks that the action was audited for the user
+func checkAudit(t *testing.T, audits *recordingAuditLogs, action string, userID *uint) {
+	t.Helper()
+
+	for _, log := range audits.logs {
+		if log.Action == action && (log.UserID == nil) == (userID == nil) && (userID == nil || *log.UserID == *userID) {
+			return
+		}
+	}
+	t.Errorf("got audit logs %+v, want %s", audits.logs, action)
+}
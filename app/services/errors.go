@@ -0,0 +1,44 @@
+package services
+
+import (
+	"MyGramAPI/app/apperror"
+	"MyGramAPI/app/repository"
+	"MyGramAPI/pkg/openid"
+	"MyGramAPI/pkg/storage"
+	"errors"
+
+	"github.com/asaskevich/govalidator"
+)
+
+// repositoryError converts the errors of the repositories into domain errors, name is used in the messages, example: Photo not found
+func repositoryError(err error, name string) error {
+	var validation govalidator.Errors
+
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return apperror.NotFound(name + " not found")
+	case errors.Is(err, repository.ErrConflict):
+		return apperror.Conflict(name + " already exists")
+	case errors.Is(err, repository.ErrUnavailable):
+		return apperror.Unavailable("Database is unavailable, please try again later", err)
+	case errors.As(err, &validation):
+		return validationError(fieldErrors(validation))
+	}
+	return apperror.Internal(err)
+}
+
+// mediaError converts the errors of the media store into domain errors
+func mediaError(err error) error {
+	if errors.Is(err, storage.ErrNotFound) {
+		return apperror.NotFound("Media not found")
+	}
+	return apperror.Unavailable("Media storage is unavailable, please try again later", err)
+}
+
+// openidError converts the errors of the OpenID Connect provider into domain errors
+func openidError(err error) error {
+	if errors.Is(err, openid.ErrInvalid) {
+		return apperror.Unauthorized("The login could not be verified, please try again")
+	}
+	return apperror.Unavailable("The login provider is unavailable, please try again later", err)
+}
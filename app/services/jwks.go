@@ -0,0 +1,21 @@
+package services
+
+import (
+	"MyGramAPI/pkg/helpers"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetJWKS godoc
+// @Summary Get the token verification keys
+// @Description Public keys of the RS256 and EdDSA signing keys as a JSON Web Key Set, other services use them to verify the tokens. The kid header of a token selects its key
+// @Tags users
+// @Produce json
+// @Success 200 {object} helpers.JSONWebKeySet "The public keys"
+// @Router /.well-known/jwks.json [GET]
+func (s *Service) GetJWKS(c *gin.Context) {
+	// the set only changes on restart, a short cache still picks up rotated keys quickly
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, helpers.JWKS())
+}
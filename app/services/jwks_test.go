@@ -0,0 +1,128 @@
+package services
+
+import (
+	"MyGramAPI/pkg/config"
+	"MyGramAPI/pkg/helpers"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt"
+)
+
+func TestGetJWKSPublishesThePublicKeys(t *testing.T) {
+	dir := t.TempDir()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPublic, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edDER, err := x509.MarshalPKCS8PrivateKey(edKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := testConfig()
+	cfg.JWT.Keys = config.Keys{"hmac": "hmac secret"}
+	cfg.JWT.PrivateKeyFiles = config.Keys{
+		"rsa":     writePEM(t, dir, "rsa.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey)),
+		"ed25519": writePEM(t, dir, "ed25519.pem", "PRIVATE KEY", edDER),
+	}
+	cfg.JWT.KeyID = "rsa"
+	s, _ := newTestService(t, cfg)
+
+	recorder := serve("/.well-known/jwks.json", jsonRequest(http.MethodGet, "/.well-known/jwks.json", ""), s.GetJWKS)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("got status %d, want 200: %s", recorder.Code, recorder.Body)
+	}
+
+	// the secrets of the HS256 keys would let anyone sign tokens
+	for _, secret := range []string{"hmac secret", base64.RawURLEncoding.EncodeToString([]byte("hmac secret")), `"hmac"`} {
+		if strings.Contains(recorder.Body.String(), secret) {
+			t.Errorf("the key set contains %s: %s", secret, recorder.Body)
+		}
+	}
+
+	// neither are the private parts of the other keys, nor a symmetric key
+	raw := struct{ Keys []map[string]interface{} }{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &raw); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range raw.Keys {
+		for _, member := range []string{"d", "p", "q", "dp", "dq", "qi", "k"} {
+			if _, ok := key[member]; ok {
+				t.Errorf("key %v publishes %s", key["kid"], member)
+			}
+		}
+	}
+
+	set := helpers.JSONWebKeySet{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &set); err != nil {
+		t.Fatal(err)
+	}
+	if len(set.Keys) != 2 {
+		t.Fatalf("got %d keys, want the RSA and the Ed25519 ones: %s", len(set.Keys), recorder.Body)
+	}
+
+	ed, rsaJWK := set.Keys[0], set.Keys[1]
+	if ed.Kid != "ed25519" || ed.Kty != "OKP" || ed.Crv != "Ed25519" || ed.Alg != "EdDSA" || ed.Use != "sig" {
+		t.Errorf("got Ed25519 key %+v", ed)
+	}
+	if x, err := base64.RawURLEncoding.DecodeString(ed.X); err != nil || !edPublic.Equal(ed25519.PublicKey(x)) {
+		t.Errorf("got x %q, want the public key", ed.X)
+	}
+	if rsaJWK.Kid != "rsa" || rsaJWK.Kty != "RSA" || rsaJWK.Alg != "RS256" || rsaJWK.Use != "sig" {
+		t.Errorf("got RSA key %+v", rsaJWK)
+	}
+
+	// the published key verifies the tokens we issue
+	token := helpers.GenerateToken(1, 1, "alice@example.com", nil)
+	parsed, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
+		if token.Header["kid"] != rsaJWK.Kid {
+			t.Errorf("got a token of the key %v, want %s", token.Header["kid"], rsaJWK.Kid)
+		}
+		return publicRSAKey(t, rsaJWK), nil
+	})
+	if err != nil || !parsed.Valid {
+		t.Errorf("the token doesn't verify with the published key: %v", err)
+	}
+}
+
+// publicRSAKey returns the RSA key of the JSON web key
+func publicRSAKey(t *testing.T, key helpers.JSONWebKey) *rsa.PublicKey {
+	t.Helper()
+
+	n, err := base64.RawURLEncoding.DecodeString(key.N)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(key.E)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+}
+
+// writePEM writes the PEM encoded key to the directory and returns its path
+func writePEM(t *testing.T, dir, name, blockType string, der []byte) string {
+	t.Helper()
+
+	file := filepath.Join(dir, name)
+	if err := os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"MyGramAPI/app/apperror"
+	"MyGramAPI/app/entity"
+	"MyGramAPI/pkg/mail"
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// mailToken issues a one-time token for the purpose and mails it to the user.
+// The text is formatted with the username, how long the token is valid and the link, the link is the bare token when page is empty.
+func (s *Service) mailToken(ctx context.Context, user entity.User, purpose string, ttl time.Duration, page, subject, text string) error {
+	token, err := s.issueUserToken(ctx, user.ID, purpose, ttl)
+	if err != nil {
+		return err
+	}
+
+	link := token
+	if page != "" {
+		link = withToken(page, token)
+	}
+
+	err = s.Mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: subject,
+		Body:    fmt.Sprintf(text, user.Username, validity(ttl), link),
+	})
+	if err != nil {
+		return apperror.Unavailable("Mail can't be sent, please try again later", err)
+	}
+	return nil
+}
+
+// withToken adds the token to the query of the page, the config makes sure the page is a valid URL
+func withToken(page, token string) string {
+	link, _ := url.Parse(page)
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+	return link.String()
+}
+
+// validity writes how long a token is valid the way it's read in a mail, example: 30 minutes
+func validity(ttl time.Duration) string {
+	amount, unit := int(ttl/time.Minute), "minute"
+	if ttl >= time.Hour && ttl%time.Hour == 0 {
+		amount, unit = int(ttl/time.Hour), "hour"
+	}
+
+	if amount == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", amount, unit)
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"bufio"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetMedia godoc
+// @Summary Get an uploaded media
+// @Description Download the content of an uploaded photo, only available when the media is stored on the local disk
+// @Tags media
+// @Produce octet-stream
+// @Param key path string true "media key"
+// @Success 200 {file} file "The content of the media"
+// @Failure 404  {object}  entity.Response "If the media doesn't exist, error will appear"
+// @Failure 503  {object}  entity.Response "If the media storage is unavailable, error will appear"
+// @Router /media/{key} [GET]
+func (s *Service) GetMedia(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+
+	file, err := s.Media.Open(c.Request.Context(), key)
+	if err != nil {
+		c.Error(mediaError(err))
+		return
+	}
+	defer file.Close()
+
+	// the keys have no extension, so sniff the content type from the first bytes
+	reader := bufio.NewReader(file)
+	head, _ := reader.Peek(512)
+
+	c.DataFromReader(http.StatusOK, -1, http.DetectContentType(head), reader, nil)
+}
@@ -0,0 +1,237 @@
+package services
+
+import (
+	"MyGramAPI/app/apperror"
+	"MyGramAPI/app/entity"
+	"MyGramAPI/app/repository"
+	"MyGramAPI/pkg/helpers"
+	"MyGramAPI/pkg/openid"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// oidcCookie keeps the state, the nonce and the PKCE verifier of a login between the redirect and the callback
+	oidcCookie     = "mygram_oidc"
+	oidcCookiePath = "/api/v1/users/oidc"
+)
+
+// UserOIDCLogin godoc
+// @Summary Log in with the OpenID Connect provider
+// @Description Redirect to the login page of the OpenID Connect provider, which sends the user back to /api/v1/users/oidc/callback. Open it in the browser, the login is tied to the browser by a cookie
+// @Tags users
+// @Produce json
+// @Success 302 {object} entity.Response "Redirect to the login page of the provider"
+// @Failure 404  {object}  entity.Response "If the OpenID Connect login isn't configured"
+// @Failure 503  {object}  entity.Response "If the provider is unavailable, error will appear"
+// @Router /api/v1/users/oidc/login [get]
+func (s *Service) UserOIDCLogin(c *gin.Context) {
+	if s.OIDC == nil {
+		c.Error(apperror.NotFound("OpenID Connect login is not enabled"))
+		return
+	}
+
+	var values [3]string
+	for i := range values {
+		value, _, err := helpers.RandomToken()
+		if err != nil {
+			c.Error(apperror.Internal(err))
+			return
+		}
+		values[i] = value
+	}
+	state, nonce, verifier := values[0], values[1], values[2]
+
+	authURL, err := s.OIDC.AuthCodeURL(c.Request.Context(), state, nonce, verifier)
+	if err != nil {
+		c.Error(openidError(err))
+		return
+	}
+
+	// the values are random base64url strings, they never contain the dots
+	s.setOIDCCookie(c, strings.Join(values[:], "."), int(s.OIDCLoginTTL.Seconds()))
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// UserOIDCCallback godoc
+// @Summary Finish the login with the OpenID Connect provider
+// @Description The provider redirects here after the login. The first login links the provider account to the MyGram account with the same verified email or creates a new account, every login then returns the tokens of a normal login
+// @Tags users
+// @Produce json
+// @Param code query string true "authorization code returned by the provider"
+// @Param state query string true "state returned by the provider"
+// @Success 200 {object} entity.Response "If the login is verified, you will get an access token and a refresh token, or a challenge token when two-factor authentication is enabled"
+// @Failure 400  {object}  entity.Response "If the login has expired or was started in another browser"
+// @Failure 401  {object}  entity.Response "If the provider refused the login or it can't be verified"
+// @Failure 403  {object}  entity.Response "If the provider didn't share the email"
+// @Failure 404  {object}  entity.Response "If the OpenID Connect login isn't configured"
+// @Failure 409  {object}  entity.Response "If an account already uses the email but one of the emails isn't verified"
+// @Failure 503  {object}  entity.Response "If the provider is unavailable, error will appear"
+// @Router /api/v1/users/oidc/callback [get]
+func (s *Service) UserOIDCCallback(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	if s.OIDC == nil {
+		c.Error(apperror.NotFound("OpenID Connect login is not enabled"))
+		return
+	}
+
+	// the cookie is for one login only
+	cookie, _ := c.Cookie(oidcCookie)
+	s.setOIDCCookie(c, "", -1)
+
+	if reason := c.Query("error"); reason != "" {
+		c.Error(apperror.Unauthorized("The provider refused the login: " + reason))
+		return
+	}
+
+	values := strings.Split(cookie, ".")
+	if len(values) != 3 || subtle.ConstantTimeCompare([]byte(values[0]), []byte(c.Query("state"))) != 1 {
+		c.Error(apperror.Validation("The login has expired or was started in another browser, please try again", nil))
+		return
+	}
+	nonce, verifier := values[1], values[2]
+
+	Identity, err := s.OIDC.Exchange(ctx, c.Query("code"), nonce, verifier)
+	if err != nil {
+		if errors.Is(err, openid.ErrInvalid) {
+			log.Printf("error verifying the OpenID Connect login: %v", err)
+		}
+		c.Error(openidError(err))
+		return
+	}
+
+	User, err := s.externalUser(ctx, Identity, c.ClientIP())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	// the provider only replaces the password, the second factor is still asked
+	if User.TOTPEnabled {
+		DataChallenge, err := s.loginChallenge(ctx, User)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, entity.Response{
+			Success: true,
+			Message: "Enter the code of your authenticator app to finish logging in",
+			Data:    DataChallenge,
+		})
+		return
+	}
+
+	DataLogin, err := s.startSession(ctx, User, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entity.Response{
+		Success: true,
+		Message: "User logged in successfully",
+		Data:    DataLogin,
+	})
+}
+
+// externalUser returns the user the identity is linked to. On its first login the identity is linked to the account
+// with the same email, or a new account is created.
+func (s *Service) externalUser(ctx context.Context, identity openid.Identity, ip string) (entity.User, error) {
+	User, err := s.Users.FindByIdentity(ctx, identity.Issuer, identity.Subject)
+	if !errors.Is(err, repository.ErrNotFound) {
+		if err != nil {
+			return User, repositoryError(err, "User")
+		}
+		return User, nil
+	}
+
+	if identity.Email == "" {
+		return User, apperror.Forbidden("The provider didn't share your email, allow it and try again")
+	}
+	LinkedIdentity := entity.LinkedIdentity{Issuer: identity.Issuer, Subject: identity.Subject, Email: identity.Email}
+
+	User, err = s.Users.FindByEmail(ctx, identity.Email)
+	if errors.Is(err, repository.ErrNotFound) {
+		return s.createExternalUser(ctx, identity, LinkedIdentity)
+	}
+	if err != nil {
+		return User, repositoryError(err, "User")
+	}
+
+	// both sides must have proven the email: linking an unverified account would let whoever registered
+	// someone else's email keep its password, an unverified provider email would hand the account to a stranger
+	if !identity.EmailVerified || !User.EmailVerified {
+		return User, apperror.Conflict("An account already uses this email, log in with your password instead")
+	}
+
+	LinkedIdentity.UserID = User.ID
+	if err := s.Users.LinkIdentity(ctx, &LinkedIdentity); err != nil {
+		return User, repositoryError(err, "Identity")
+	}
+	s.audit(ctx, entity.AuditIdentityLinked, &User.ID, ip, "linked "+identity.Issuer+" by the email "+identity.Email)
+	return User, nil
+}
+
+// createExternalUser creates the account of a first external login, its password is random
+// so it can only log in with the provider until a password is set with the reset flow
+func (s *Service) createExternalUser(ctx context.Context, identity openid.Identity, linked entity.LinkedIdentity) (entity.User, error) {
+	password, _, err := helpers.RandomToken()
+	if err != nil {
+		return entity.User{}, apperror.Internal(err)
+	}
+	hash, err := helpers.HashPass(password)
+	if err != nil {
+		return entity.User{}, apperror.Internal(err)
+	}
+
+	username := identity.Username
+	if username == "" {
+		username, _, _ = strings.Cut(identity.Email, "@")
+	}
+
+	// the preferred username may be taken, a few numbered variants are tried before giving up
+	for attempt := 0; ; attempt++ {
+		User := entity.User{
+			Username:      username,
+			Email:         identity.Email,
+			Password:      hash,
+			EmailVerified: identity.EmailVerified,
+		}
+		if attempt > 0 {
+			User.Username = fmt.Sprintf("%s%04d", username, rand.Intn(10000))
+		}
+		LinkedIdentity := linked
+
+		err := s.Users.CreateWithIdentity(ctx, &User, &LinkedIdentity)
+		if errors.Is(err, repository.ErrConflict) && attempt < 5 {
+			continue
+		}
+		if err != nil {
+			return User, repositoryError(err, "User")
+		}
+
+		if !User.EmailVerified {
+			if err := s.sendVerification(ctx, User); err != nil {
+				log.Printf("error sending the verification mail of user %d: %v", User.ID, err)
+			}
+		}
+		return User, nil
+	}
+}
+
+// setOIDCCookie sets the login cookie, a negative maxAge deletes it
+func (s *Service) setOIDCCookie(c *gin.Context, value string, maxAge int) {
+	// lax, since the provider sends the user back with a cross-site redirect
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcCookie, value, maxAge, oidcCookiePath, "", strings.HasPrefix(s.OIDCRedirectURL, "https://"), true)
+}
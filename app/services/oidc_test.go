@@ -0,0 +1,368 @@
+package services
+
+import (
+	"MyGramAPI/app/entity"
+	"MyGramAPI/app/repository"
+	"MyGramAPI/pkg/config"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+func TestUserOIDCCallbackCreatesAUser(t *testing.T) {
+	ctx := context.Background()
+	provider := newOIDCProvider(t)
+	s, _ := newTestService(t, provider.config())
+	claims := jwt.MapClaims{"sub": "carol-1", "email": "carol@example.com", "email_verified": true, "preferred_username": "carol"}
+
+	cookie, login := startOIDCLogin(t, s)
+	recorder := oidcCallback(s, cookie, url.Values{"code": {provider.authorize(t, login, claims)}, "state": {login.Get("state")}})
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("first login: got status %d, want 200: %s", recorder.Code, recorder.Body)
+	}
+	DataLogin := entity.DataLogin{}
+	decode(t, recorder, &DataLogin)
+	if status := authenticate(s, DataLogin.Token); status != http.StatusNoContent {
+		t.Errorf("access token of the first login: got status %d, want 204", status)
+	}
+
+	User, err := s.Users.FindByIdentity(ctx, provider.URL, "carol-1")
+	if err != nil {
+		t.Fatalf("the identity isn't linked: %v", err)
+	}
+	if User.Username != "carol" || User.Email != "carol@example.com" || !User.EmailVerified {
+		t.Errorf("got user %+v, want carol with a verified email", User)
+	}
+
+	// the next login finds the same account
+	cookie, login = startOIDCLogin(t, s)
+	recorder = oidcCallback(s, cookie, url.Values{"code": {provider.authorize(t, login, claims)}, "state": {login.Get("state")}})
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("second login: got status %d, want 200: %s", recorder.Code, recorder.Body)
+	}
+	if Users, _, err := s.Users.FindAll(ctx, repository.Page{Limit: 10}); err != nil || len(Users) != 1 {
+		t.Errorf("got users %v and %v, want carol alone", Users, err)
+	}
+}
+
+func TestUserOIDCCallbackVerifiesTheLogin(t *testing.T) {
+	provider := newOIDCProvider(t)
+	s, _ := newTestService(t, provider.config())
+	claims := jwt.MapClaims{"sub": "carol-1", "email": "carol@example.com", "email_verified": true}
+
+	tests := []struct {
+		name   string
+		change func(cookie *http.Cookie, login, callback url.Values, claims jwt.MapClaims)
+		status int
+	}{
+		{"state of another login", func(cookie *http.Cookie, login, callback url.Values, claims jwt.MapClaims) {
+			callback.Set("state", "other")
+		}, http.StatusBadRequest},
+		{"without the cookie", func(cookie *http.Cookie, login, callback url.Values, claims jwt.MapClaims) {
+			cookie.Value = ""
+		}, http.StatusBadRequest},
+		// the provider only hands the token to whoever holds the verifier of the challenge
+		{"verifier of another login", func(cookie *http.Cookie, login, callback url.Values, claims jwt.MapClaims) {
+			values := strings.Split(cookie.Value, ".")
+			cookie.Value = values[0] + "." + values[1] + ".other"
+		}, http.StatusUnauthorized},
+		{"nonce of another login", func(cookie *http.Cookie, login, callback url.Values, claims jwt.MapClaims) {
+			claims["nonce"] = "other"
+		}, http.StatusUnauthorized},
+		{"refused by the provider", func(cookie *http.Cookie, login, callback url.Values, claims jwt.MapClaims) {
+			callback.Set("error", "access_denied")
+		}, http.StatusUnauthorized},
+	}
+
+	for _, test := range tests {
+		cookie, login := startOIDCLogin(t, s)
+		claims := copyClaims(claims)
+		callback := url.Values{"state": {login.Get("state")}}
+		test.change(cookie, login, callback, claims)
+		callback.Set("code", provider.authorize(t, login, claims))
+
+		recorder := oidcCallback(s, cookie, callback)
+		if recorder.Code != test.status {
+			t.Errorf("%s: got status %d, want %d: %s", test.name, recorder.Code, test.status, recorder.Body)
+		}
+		if _, err := s.Users.FindByEmail(context.Background(), "carol@example.com"); !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("%s: got a user created and %v, want none", test.name, err)
+		}
+	}
+}
+
+func TestUserOIDCCallbackLinksAVerifiedEmail(t *testing.T) {
+	tests := []struct {
+		name             string
+		accountVerified  bool
+		providerVerified bool
+		status           int
+	}{
+		{"both emails verified", true, true, http.StatusOK},
+		{"account email unverified", false, true, http.StatusConflict},
+		{"provider email unverified", true, false, http.StatusConflict},
+	}
+
+	for _, test := range tests {
+		ctx := context.Background()
+		provider := newOIDCProvider(t)
+		s, _ := newTestService(t, provider.config())
+		Alice := createUser(t, s, "alice")
+		if test.accountVerified {
+			if err := s.Users.MarkEmailVerified(ctx, Alice.ID); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		claims := jwt.MapClaims{"sub": "alice-1", "email": "alice@example.com", "email_verified": test.providerVerified}
+		cookie, login := startOIDCLogin(t, s)
+		recorder := oidcCallback(s, cookie, url.Values{"code": {provider.authorize(t, login, claims)}, "state": {login.Get("state")}})
+		if recorder.Code != test.status {
+			t.Fatalf("%s: got status %d, want %d: %s", test.name, recorder.Code, test.status, recorder.Body)
+		}
+
+		User, err := s.Users.FindByIdentity(ctx, provider.URL, "alice-1")
+		if test.status == http.StatusOK && (err != nil || User.ID != Alice.ID) {
+			t.Errorf("%s: got user %d and %v, want the identity linked to alice", test.name, User.ID, err)
+		}
+		if test.status != http.StatusOK && !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("%s: got user %d and %v, want the identity left unlinked", test.name, User.ID, err)
+		}
+	}
+}
+
+func TestUserOIDCCallbackStillAsksForTheCode(t *testing.T) {
+	ctx := context.Background()
+	provider := newOIDCProvider(t)
+	s, _ := newTestService(t, provider.config())
+	Alice := createUser(t, s, "alice")
+	if err := s.Users.MarkEmailVerified(ctx, Alice.ID); err != nil {
+		t.Fatal(err)
+	}
+	secret := enableTOTP(t, s, Alice)
+
+	claims := jwt.MapClaims{"sub": "alice-1", "email": "alice@example.com", "email_verified": true}
+	cookie, login := startOIDCLogin(t, s)
+	recorder := oidcCallback(s, cookie, url.Values{"code": {provider.authorize(t, login, claims)}, "state": {login.Get("state")}})
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("got status %d, want 200: %s", recorder.Code, recorder.Body)
+	}
+
+	// the provider replaces the password only, no tokens are given before the code
+	response := decode(t, recorder, nil)
+	if strings.Contains(string(response.Data), `"token"`) {
+		t.Fatalf("got tokens without the code: %s", response.Data)
+	}
+	DataChallenge := entity.DataChallenge{}
+	decode(t, recorder, &DataChallenge)
+	if !DataChallenge.MFARequired || DataChallenge.ChallengeToken == "" {
+		t.Fatalf("got %+v, want a challenge", DataChallenge)
+	}
+
+	if recorder := sendCode(s, DataChallenge.ChallengeToken, totpCode(t, secret, time.Now())); recorder.Code != http.StatusOK {
+		t.Errorf("code of the challenge: got status %d, want 200: %s", recorder.Code, recorder.Body)
+	}
+}
+
+// startOIDCLogin starts a login and returns its cookie and the query of the redirect to the provider
+func startOIDCLogin(t *testing.T, s *Service) (*http.Cookie, url.Values) {
+	t.Helper()
+
+	recorder := serve("/api/v1/users/oidc/login", jsonRequest(http.MethodGet, "/api/v1/users/oidc/login", ""), s.UserOIDCLogin)
+	if recorder.Code != http.StatusFound {
+		t.Fatalf("login: got status %d, want 302: %s", recorder.Code, recorder.Body)
+	}
+	location, err := url.Parse(recorder.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, cookie := range recorder.Result().Cookies() {
+		if cookie.Name == oidcCookie {
+			if !cookie.HttpOnly || cookie.Path != oidcCookiePath {
+				t.Errorf("got cookie %+v, want it HTTP only on the OpenID Connect routes", cookie)
+			}
+			return cookie, location.Query()
+		}
+	}
+	t.Fatal("the login sets no cookie")
+	return nil, nil
+}
+
+// oidcCallback sends the browser back from the provider with the cookie of its login
+func oidcCallback(s *Service, cookie *http.Cookie, query url.Values) *httptest.ResponseRecorder {
+	request := jsonRequest(http.MethodGet, "/api/v1/users/oidc/callback?"+query.Encode(), "")
+	if cookie.Value != "" {
+		request.AddCookie(cookie)
+	}
+	return serve("/api/v1/users/oidc/callback", request, s.UserOIDCCallback)
+}
+
+// copyClaims returns a copy the test can change
+func copyClaims(claims jwt.MapClaims) jwt.MapClaims {
+	copied := jwt.MapClaims{}
+	for name, value := range claims {
+		copied[name] = value
+	}
+	return copied
+}
+
+// oidcProvider is an OpenID Connect provider serving its discovery document, its token endpoint and its keys,
+// the users log in at once with the claims given to authorize
+type oidcProvider struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+
+	mu     sync.Mutex
+	logins map[string]oidcLogin
+}
+
+// oidcLogin is a login the provider issued a code for
+type oidcLogin struct {
+	challenge string
+	claims    jwt.MapClaims
+}
+
+func newOIDCProvider(t *testing.T) *oidcProvider {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := &oidcProvider{key: key, logins: map[string]oidcLogin{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"issuer":                                provider.URL,
+			"authorization_endpoint":                provider.URL + "/authorize",
+			"token_endpoint":                        provider.URL + "/token",
+			"jwks_uri":                              provider.URL + "/keys",
+			"response_types_supported":              []string{"code"},
+			"subject_types_supported":               []string{"public"},
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "provider",
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.PublicKey.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", provider.token)
+
+	provider.Server = httptest.NewServer(mux)
+	t.Cleanup(provider.Close)
+	return provider
+}
+
+// config returns the settings of a service logging in with the provider
+func (p *oidcProvider) config() config.Config {
+	cfg := testConfig()
+	cfg.OIDC.Issuer = p.URL
+	cfg.OIDC.ClientID = "mygram"
+	cfg.OIDC.ClientSecret = "client secret"
+	cfg.OIDC.RedirectURL = "http://localhost:8082/api/v1/users/oidc/callback"
+	return cfg
+}
+
+// authorize logs the user in on the login page the query was sent to, and returns the code of the redirect.
+// The nonce of the query goes in the ID token unless the claims set one.
+func (p *oidcProvider) authorize(t *testing.T, query url.Values, claims jwt.MapClaims) string {
+	t.Helper()
+
+	if query.Get("client_id") != "mygram" || query.Get("response_type") != "code" || query.Get("code_challenge_method") != "S256" {
+		t.Fatalf("got the login %v, want the code flow of mygram with PKCE", query)
+	}
+	if query.Get("code_challenge") == "" || query.Get("nonce") == "" || query.Get("state") == "" {
+		t.Fatalf("got the login %v, want a challenge, a nonce and a state", query)
+	}
+
+	claims = copyClaims(claims)
+	if _, ok := claims["nonce"]; !ok {
+		claims["nonce"] = query.Get("nonce")
+	}
+	code := randomCode(t)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.logins[code] = oidcLogin{challenge: query.Get("code_challenge"), claims: claims}
+	return code
+}
+
+// token trades a code for the ID token of its login, once, and only with the verifier of its challenge
+func (p *oidcProvider) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	p.mu.Lock()
+	login, ok := p.logins[r.PostForm.Get("code")]
+	delete(p.logins, r.PostForm.Get("code"))
+	p.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != login.challenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	claims := copyClaims(login.claims)
+	claims["iss"] = p.URL
+	claims["aud"] = "mygram"
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(time.Minute).Unix()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "provider"
+	idToken, err := token.SignedString(p.key)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": "access",
+		"token_type":   "Bearer",
+		"expires_in":   60,
+		"id_token":     idToken,
+	})
+}
+
+// randomCode returns a new authorization code
+func randomCode(t *testing.T) string {
+	t.Helper()
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
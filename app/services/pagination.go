@@ -0,0 +1,55 @@
+package services
+
+import (
+	"MyGramAPI/app/entity"
+	"MyGramAPI/app/repository"
+	"math"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPerPage = 20
+	maxPerPage     = 100
+)
+
+// pageFromQuery reads the page and per_page query parameters, invalid values fall back to the defaults
+func pageFromQuery(c *gin.Context) (int, int) {
+	page, err := strconv.Atoi(c.Query("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	perPage, err := strconv.Atoi(c.Query("per_page"))
+	if err != nil || perPage < 1 {
+		perPage = defaultPerPage
+	}
+	if perPage > maxPerPage {
+		perPage = maxPerPage
+	}
+
+	// past this page the offset of repositoryPage would overflow, no list is that long anyway
+	if page > math.MaxInt/perPage {
+		page = math.MaxInt / perPage
+	}
+
+	return page, perPage
+}
+
+// repositoryPage converts the page number into the slice the repositories understand
+func repositoryPage(page, perPage int) repository.Page {
+	return repository.Page{Limit: perPage, Offset: (page - 1) * perPage}
+}
+
+// pagination returns the metadata sent with a page of a list
+func pagination(page, perPage int, total int64) *entity.Pagination {
+	totalPages := int((total + int64(perPage) - 1) / int64(perPage))
+
+	return &entity.Pagination{
+		Page:       page,
+		PerPage:    perPage,
+		Total:      total,
+		TotalPages: totalPages,
+	}
+}
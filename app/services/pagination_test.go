@@ -0,0 +1,51 @@
+package services
+
+import (
+	"MyGramAPI/app/repository"
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestPageFromQuery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		query   string
+		page    int
+		perPage int
+	}{
+		{"", 1, defaultPerPage},
+		{"?page=3&per_page=10", 3, 10},
+		{"?page=0&per_page=0", 1, defaultPerPage},
+		{"?page=-2&per_page=-5", 1, defaultPerPage},
+		{"?page=two&per_page=ten", 1, defaultPerPage},
+		{"?per_page=1000", 1, maxPerPage},
+		{"?page=" + strconv.Itoa(math.MaxInt), math.MaxInt / defaultPerPage, defaultPerPage},
+		{"?page=" + strconv.Itoa(math.MaxInt) + "&per_page=1", math.MaxInt, 1},
+		{"?page=" + strconv.Itoa(math.MaxInt) + "&per_page=" + strconv.Itoa(maxPerPage), math.MaxInt / maxPerPage, maxPerPage},
+	}
+
+	for _, test := range tests {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/photos"+test.query, nil)
+
+		page, perPage := pageFromQuery(c)
+		if page != test.page || perPage != test.perPage {
+			t.Errorf("%q: got page %d of %d, want page %d of %d", test.query, page, perPage, test.page, test.perPage)
+		}
+
+		Page := repositoryPage(page, perPage)
+		if Page.Offset < 0 || Page.Limit != perPage {
+			t.Errorf("%q: got the repository page %+v, want a non-negative offset", test.query, Page)
+		}
+		if Photo, _, err := repository.NewMemory().Photos.FindAll(context.Background(), Page); err != nil || len(Photo) != 0 {
+			t.Errorf("%q: got %d photos and %v, want an empty page", test.query, len(Photo), err)
+		}
+	}
+}
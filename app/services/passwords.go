@@ -0,0 +1,190 @@
+package services
+
+import (
+	"MyGramAPI/app/apperror"
+	"MyGramAPI/app/entity"
+	"MyGramAPI/app/repository"
+	"MyGramAPI/pkg/helpers"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UserRequestPasswordReset godoc
+// @Summary Request a password reset
+// @Description Send a password reset link to the email. The answer is the same whether the email is registered or not, so it can't be used to find accounts. The requests are limited per email and per IP address
+// @Tags users
+// @Consumes ({mpfd,json})
+// @Produce json
+// @Param email formData string true "User's email"
+// @Success 200 {object} entity.Response "If the email is valid, a reset link is sent when it's registered"
+// @Failure 400  {object}  entity.Response "If the email is invalid, every invalid field is listed in error.details"
+// @Failure 429  {object}  entity.Response "If there were too many reset requests for the email or from your network, wait for the Retry-After header"
+// @Router /api/v1/users/password-reset [post]
+func (s *Service) UserRequestPasswordReset(c *gin.Context) {
+	contentType := helpers.GetContentType(c)
+	Reset := entity.PasswordResetRequest{}
+
+	if contentType == appJSON {
+		c.ShouldBindJSON(&Reset)
+	} else {
+		c.ShouldBind(&Reset)
+	}
+
+	if fields := validate(&Reset); len(fields) > 0 {
+		c.Error(validationError(fields))
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := s.checkResetAttempts(ctx, Reset.Email, c.ClientIP()); err != nil {
+		c.Error(err)
+		return
+	}
+
+	User, err := s.Users.FindByEmail(ctx, Reset.Email)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		c.Error(repositoryError(err, "User"))
+		return
+	}
+
+	// a failed mail is only logged, answering it would tell the email is registered
+	if err == nil {
+		if err := s.sendPasswordReset(ctx, User); err != nil {
+			log.Printf("error sending the password reset mail of user %d: %v", User.ID, err)
+		}
+	}
+
+	c.JSON(http.StatusOK, entity.Response{
+		Success: true,
+		Message: "If the email is registered, a password reset link has been sent to it",
+		Data:    nil,
+	})
+}
+
+// UserConfirmPasswordReset godoc
+// @Summary Reset the password
+// @Description Set a new password with the token of the reset link. A token works once, and every device is logged out after the reset
+// @Tags users
+// @Consumes ({mpfd,json})
+// @Produce json
+// @Param token formData string true "token of the reset link"
+// @Param password formData string true "new password"
+// @Success 200 {object} entity.Response "If the token is valid, the password is changed"
+// @Failure 400  {object}  entity.Response "If the token is invalid, expired or already used, or the password is invalid"
+// @Router /api/v1/users/password-reset/confirm [post]
+func (s *Service) UserConfirmPasswordReset(c *gin.Context) {
+	ctx := c.Request.Context()
+	contentType := helpers.GetContentType(c)
+	Reset := entity.PasswordResetConfirm{}
+
+	if contentType == appJSON {
+		c.ShouldBindJSON(&Reset)
+	} else {
+		c.ShouldBind(&Reset)
+	}
+
+	if fields := validate(&Reset); len(fields) > 0 {
+		c.Error(validationError(fields))
+		return
+	}
+
+	UserToken, err := s.useUserToken(ctx, entity.PurposePasswordReset, Reset.Token, apperror.Validation("Reset token is invalid or has expired", nil))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	hash, err := helpers.HashPass(Reset.Password)
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	if err := s.Users.UpdatePassword(ctx, UserToken.UserID, hash); err != nil {
+		c.Error(repositoryError(err, "User"))
+		return
+	}
+
+	// the reset link was opened from the mailbox, so the email is verified too
+	if err := s.Users.MarkEmailVerified(ctx, UserToken.UserID); err != nil {
+		c.Error(repositoryError(err, "User"))
+		return
+	}
+
+	// whoever knew the old password must not stay logged in
+	if err := s.logoutAll(ctx, UserToken.UserID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entity.Response{
+		Success: true,
+		Message: "Password has been reset successfully, please log in again",
+		Data:    nil,
+	})
+}
+
+// checkResetAttempts rejects a password reset request while the email or the IP address made too many of them,
+// otherwise the request is counted. Unregistered emails are counted too, so the limit tells nothing about them.
+func (s *Service) checkResetAttempts(ctx context.Context, email, ip string) error {
+	now := time.Now()
+
+	account, err := s.LoginAttempts.Get(ctx, resetKey(email))
+	if err != nil {
+		return apperror.Unavailable("Login attempt store is unavailable, please try again later", err)
+	}
+	address, err := s.LoginAttempts.Get(ctx, resetIPKey(ip))
+	if err != nil {
+		return apperror.Unavailable("Login attempt store is unavailable, please try again later", err)
+	}
+	if wait, _ := s.ResetEmailPolicy.Wait(account, now); wait > 0 {
+		return apperror.TooManyRequests("Too many password reset requests for this email, please try again later", wait)
+	}
+	if wait, _ := s.ResetIPPolicy.Wait(address, now); wait > 0 {
+		return apperror.TooManyRequests("Too many password reset requests from your network, please try again later", wait)
+	}
+
+	if _, err := s.LoginAttempts.Fail(ctx, resetKey(email), now, s.ResetEmailPolicy.Since(now)); err != nil {
+		log.Printf("error counting the password reset request of %s: %v", email, err)
+	}
+	if _, err := s.LoginAttempts.Fail(ctx, resetIPKey(ip), now, s.ResetIPPolicy.Since(now)); err != nil {
+		log.Printf("error counting the password reset request from %s: %v", ip, err)
+	}
+	return nil
+}
+
+// resetKey and resetIPKey name the password reset counters, next to the failed login ones in the same store
+func resetKey(email string) string {
+	return "reset-" + accountKey(email)
+}
+
+func resetIPKey(ip string) string {
+	return "reset-" + ipKey(ip)
+}
+
+// sendPasswordReset mails a new reset link to the user
+func (s *Service) sendPasswordReset(ctx context.Context, user entity.User) error {
+	return s.mailToken(ctx, user, entity.PurposePasswordReset, s.PasswordResetTTL, s.PasswordResetURL,
+		"Reset your MyGram password",
+		"Hi %s,\n\n"+
+			"Someone asked to reset the password of your MyGram account. "+
+			"Use this within %s to choose a new password:\n\n%s\n\n"+
+			"If it wasn't you, ignore this mail and your password stays the same.\n")
+}
+
+// rehashPassword replaces the hash of the user by one of the current algorithm and parameters,
+// a failure is only logged since the old hash still works
+func (s *Service) rehashPassword(ctx context.Context, userID uint, password string) {
+	hash, err := helpers.HashPass(password)
+	if err == nil {
+		err = s.Users.UpdatePassword(ctx, userID, hash)
+	}
+	if err != nil {
+		log.Printf("error upgrading the password hash of user %d: %v", userID, err)
+	}
+}
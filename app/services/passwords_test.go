@@ -0,0 +1,219 @@
+package services
+
+import (
+	"MyGramAPI/app/entity"
+	"MyGramAPI/app/repository"
+	"MyGramAPI/pkg/attempts"
+	"MyGramAPI/pkg/config"
+	"MyGramAPI/pkg/helpers"
+	"MyGramAPI/pkg/mail"
+	"MyGramAPI/pkg/password"
+	"MyGramAPI/pkg/revocation"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	netmail "net/mail"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestUserRequestPasswordResetMailsTheLink(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	cfg := config.Default()
+	cfg.Auth.PasswordResetURL = "https://mygram.example/reset"
+	mailer, err := mail.NewFile(dir, &netmail.Address{Address: "no-reply@mygram.example"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := New(repository.NewMemory(), nil, revocation.NewMemory(), attempts.NewMemory(), mailer, cfg)
+
+	User := entity.User{Username: "alice", Email: "alice@example.com", Password: "secret123", Age: 20}
+	if err := s.Users.Create(ctx, &User); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, email := range []string{"alice@example.com", "nobody@example.com"} {
+		recorder := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(recorder)
+		c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/users/password-reset", strings.NewReader(`{"email":"`+email+`"}`))
+		c.Request.Header.Set("Content-Type", appJSON)
+
+		s.UserRequestPasswordReset(c)
+		if len(c.Errors) > 0 || recorder.Code != http.StatusOK {
+			t.Fatalf("reset of %s: got status %d and errors %v", email, recorder.Code, c.Errors)
+		}
+	}
+
+	// only the registered email gets a mail
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("got %d mails, want 1", len(files))
+	}
+
+	file, err := os.Open(filepath.Join(dir, files[0].Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	message, err := netmail.ReadMessage(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if to := message.Header.Get("To"); to != "<alice@example.com>" {
+		t.Errorf("got the mail sent to %q, want <alice@example.com>", to)
+	}
+
+	body, err := io.ReadAll(message.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := strings.Index(string(body), cfg.Auth.PasswordResetURL)
+	if start < 0 {
+		t.Fatalf("the mail has no reset link:\n%s", body)
+	}
+	link, err := url.Parse(strings.Fields(string(body[start:]))[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the link carries a token that resets the password of the user
+	UserToken, err := s.UserTokens.FindByHash(ctx, entity.PurposePasswordReset, helpers.HashToken(link.Query().Get("token")))
+	if err != nil {
+		t.Fatalf("the token of the link %s isn't stored: %v", link, err)
+	}
+	if UserToken.UserID != User.ID {
+		t.Errorf("got a token of user %d, want %d", UserToken.UserID, User.ID)
+	}
+}
+
+func TestUserRequestPasswordResetAnswersTheSame(t *testing.T) {
+	s, _ := newTestService(t, testConfig())
+	createUser(t, s, "alice")
+
+	registered := requestReset(s, "alice@example.com", "203.0.113.7")
+	unknown := requestReset(s, "nobody@example.com", "203.0.113.7")
+
+	// a mail that can't be sent is only logged
+	s.Mailer = failingMailer{}
+	unsent := requestReset(s, "alice@example.com", "198.51.100.1")
+
+	for name, recorder := range map[string]*httptest.ResponseRecorder{"unknown email": unknown, "unsent mail": unsent} {
+		if recorder.Code != registered.Code || recorder.Body.String() != registered.Body.String() {
+			t.Errorf("%s: got %d %s, want the answer of a registered email %d %s",
+				name, recorder.Code, recorder.Body, registered.Code, registered.Body)
+		}
+	}
+	if registered.Code != http.StatusOK {
+		t.Errorf("got status %d, want 200: %s", registered.Code, registered.Body)
+	}
+}
+
+func TestUserRequestPasswordResetIsThrottled(t *testing.T) {
+	cfg := testConfig()
+	cfg.Auth.PasswordResetEmailLimit = 2
+	cfg.Auth.PasswordResetIPLimit = 4
+	s, dir := newTestService(t, cfg)
+	createUser(t, s, "alice")
+
+	tests := []struct {
+		email  string
+		ip     string
+		status int
+	}{
+		{"alice@example.com", "203.0.113.7", http.StatusOK},
+		{"alice@example.com", "203.0.113.7", http.StatusOK},
+		// the third request for the email waits, whatever the case of the email or the network
+		{"alice@example.com", "203.0.113.7", http.StatusTooManyRequests},
+		{"ALICE@example.com", "198.51.100.1", http.StatusTooManyRequests},
+		// unknown emails are limited the same way
+		{"nobody@example.com", "203.0.113.7", http.StatusOK},
+		{"nobody@example.com", "203.0.113.7", http.StatusOK},
+		{"nobody@example.com", "198.51.100.1", http.StatusTooManyRequests},
+		// the network made four requests, the rejected ones aren't counted
+		{"bob@example.com", "203.0.113.7", http.StatusTooManyRequests},
+		{"bob@example.com", "198.51.100.1", http.StatusOK},
+	}
+	for i, test := range tests {
+		recorder := requestReset(s, test.email, test.ip)
+		if recorder.Code != test.status {
+			t.Fatalf("request %d for %s from %s: got status %d, want %d: %s", i, test.email, test.ip, recorder.Code, test.status, recorder.Body)
+		}
+		if test.status == http.StatusTooManyRequests {
+			if retryAfter, _ := strconv.Atoi(recorder.Header().Get("Retry-After")); retryAfter <= 0 || retryAfter > 3600 {
+				t.Errorf("request %d: got Retry-After %q, want the rest of the hour", i, recorder.Header().Get("Retry-After"))
+			}
+		}
+	}
+
+	// only the accepted requests of alice sent a mail
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Errorf("got %d mails, want 2", len(files))
+	}
+}
+
+// TestUserLoginUpgradesALegacyHash checks that a login with a bcrypt hash replaces it by an argon2id one
+func TestUserLoginUpgradesALegacyHash(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestService(t, testConfig())
+	User := createUser(t, s, "alice")
+
+	legacy, err := (&password.Bcrypt{Cost: 4}).Hash("secret123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Users.UpdatePassword(ctx, User.ID, legacy); err != nil {
+		t.Fatal(err)
+	}
+
+	if recorder := loginWith(s, "alice@example.com", "secret123"); recorder.Code != http.StatusOK {
+		t.Fatalf("got status %d, want 200: %s", recorder.Code, recorder.Body)
+	}
+	User, err = s.Users.FindByID(ctx, User.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(User.Password, "$argon2id$") {
+		t.Fatalf("got hash %s, want an argon2id one", User.Password)
+	}
+
+	// the new hash is current and takes the same password
+	if match, rehash := helpers.ComparePass(User.Password, "secret123"); !match || rehash {
+		t.Errorf("got match %t and rehash %t, want a match of a current hash", match, rehash)
+	}
+	if recorder := loginWith(s, "alice@example.com", "secret123"); recorder.Code != http.StatusOK {
+		t.Errorf("login after the upgrade: got status %d, want 200: %s", recorder.Code, recorder.Body)
+	}
+}
+
+// requestReset asks for a password reset of the email from the IP address
+func requestReset(s *Service, email, ip string) *httptest.ResponseRecorder {
+	request := jsonRequest(http.MethodPost, "/api/v1/users/password-reset", `{"email":"`+email+`"}`)
+	request.RemoteAddr = ip + ":4321"
+	return serve("/api/v1/users/password-reset", request, s.UserRequestPasswordReset)
+}
+
+// failingMailer is a mail server that is down
+type failingMailer struct{}
+
+func (failingMailer) Send(ctx context.Context, message mail.Message) error {
+	return errors.New("connection refused")
+}
@@ -0,0 +1,189 @@
+package services
+
+import (
+	"MyGramAPI/app/entity"
+	"MyGramAPI/app/repository"
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestGetAllPhotoLoadsTheOwnersAtOnce(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestService(t, testConfig())
+	users := &countingUsers{UserRepository: s.Users}
+	s.Users = users
+
+	Alice := createUser(t, s, "alice")
+	Bob := createUser(t, s, "bob")
+
+	// the second photo and its comment were left by users who no longer exist
+	Photo := []entity.Photo{
+		{Title: "first", Photo_URL: "http://example.com/first", UserID: Alice.ID},
+		{Title: "second", Photo_URL: "http://example.com/second", UserID: 99},
+	}
+	for i := range Photo {
+		if err := s.Photos.Create(ctx, &Photo[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, comment := range []entity.Comment{
+		{Message: "nice", PhotoID: Photo[0].ID, UserID: Bob.ID},
+		{Message: "mine", PhotoID: Photo[0].ID, UserID: Alice.ID},
+		{Message: "gone", PhotoID: Photo[1].ID, UserID: 98},
+	} {
+		comment := comment
+		if err := s.Comments.Create(ctx, &comment); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	recorder := serve("/api/v1/photos", jsonRequest(http.MethodGet, "/api/v1/photos", ""), s.GetAllPhoto)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("got status %d, want 200: %s", recorder.Code, recorder.Body)
+	}
+	if users.calls != 1 {
+		t.Errorf("got %d user lookups, want 1", users.calls)
+	}
+
+	type comment struct {
+		Message  string
+		Username *string
+	}
+	data := []struct {
+		Title    string
+		Username *string
+		Comment  []comment
+	}{}
+	decode(t, recorder, &data)
+
+	usernames := map[string]*string{}
+	for _, photo := range data {
+		usernames[photo.Title] = photo.Username
+		for _, comment := range photo.Comment {
+			usernames[comment.Message] = comment.Username
+		}
+	}
+	want := map[string]string{"first": "alice", "nice": "bob", "mine": "alice", "second": "", "gone": ""}
+	for name, username := range want {
+		got, ok := usernames[name]
+		switch {
+		case !ok:
+			t.Errorf("%s is missing from the list", name)
+		case username == "" && got != nil:
+			t.Errorf("%s: got username %q, want null", name, *got)
+		case username != "" && (got == nil || *got != username):
+			t.Errorf("%s: got username %v, want %s", name, got, username)
+		}
+	}
+}
+
+func TestUpdatePhotoReportsFieldErrors(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestService(t, testConfig())
+	Alice := createUser(t, s, "alice")
+
+	Photo := entity.Photo{Title: "title", Photo_URL: "http://example.com/photo", UserID: Alice.ID}
+	if err := s.Photos.Create(ctx, &Photo); err != nil {
+		t.Fatal(err)
+	}
+	target := "/api/v1/photos/" + strconv.Itoa(int(Photo.ID))
+
+	tests := []struct {
+		name    string
+		request *http.Request
+		want    []entity.FieldError
+	}{
+		{
+			"json without title",
+			jsonRequest(http.MethodPut, target, `{"caption":"caption"}`),
+			[]entity.FieldError{{Field: "title", Code: "required", Message: "Title is required"}},
+		},
+		{
+			"form without title and with a text file",
+			multipartRequest(t, target, map[string]string{"caption": "caption"}, "notes.txt"),
+			[]entity.FieldError{
+				{Field: "title", Code: "required", Message: "Title is required"},
+				{Field: "photo_url", Code: "image", Message: "File uploaded is not an image"},
+			},
+		},
+		{
+			"form with a text file",
+			multipartRequest(t, target, map[string]string{"title": "title"}, "notes.txt"),
+			[]entity.FieldError{{Field: "photo_url", Code: "image", Message: "File uploaded is not an image"}},
+		},
+	}
+
+	for _, test := range tests {
+		recorder := serve("/api/v1/photos/:id", test.request, as(Alice.ID), s.UpdatePhoto)
+		if recorder.Code != http.StatusBadRequest {
+			t.Errorf("%s: got status %d, want 400: %s", test.name, recorder.Code, recorder.Body)
+			continue
+		}
+
+		response := decode(t, recorder, nil)
+		fields := []entity.FieldError{}
+		if response.Error == nil || response.Error.Code != "bad_request" {
+			t.Errorf("%s: got error %+v, want bad_request", test.name, response.Error)
+			continue
+		}
+		if err := json.Unmarshal(response.Error.Details, &fields); err != nil {
+			t.Fatalf("%s: the details aren't field errors: %v: %s", test.name, err, response.Error.Details)
+		}
+		if !reflect.DeepEqual(fields, test.want) {
+			t.Errorf("%s: got details %+v, want %+v", test.name, fields, test.want)
+		}
+	}
+
+	// without a file the photo is kept
+	recorder := serve("/api/v1/photos/:id", jsonRequest(http.MethodPut, target, `{"title":"new title"}`), as(Alice.ID), s.UpdatePhoto)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("valid update: got status %d, want 200: %s", recorder.Code, recorder.Body)
+	}
+	if Photo, err := s.Photos.FindByID(ctx, Photo.ID); err != nil || Photo.Title != "new title" || Photo.Photo_URL != "http://example.com/photo" {
+		t.Errorf("valid update: got %+v and %v, want the new title and the same photo", Photo, err)
+	}
+}
+
+// multipartRequest returns a PUT of a form with the fields and a file of the name as photo_url
+func multipartRequest(t *testing.T, target string, fields map[string]string, file string) *http.Request {
+	t.Helper()
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for name, value := range fields {
+		writer.WriteField(name, value)
+	}
+	part, err := writer.CreateFormFile("photo_url", file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write([]byte("not a photo"))
+	writer.Close()
+
+	request := httptest.NewRequest(http.MethodPut, target, body)
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	return request
+}
+
+// countingUsers counts the lookups of users
+type countingUsers struct {
+	repository.UserRepository
+	calls int
+}
+
+func (r *countingUsers) FindByID(ctx context.Context, id uint) (entity.User, error) {
+	r.calls++
+	return r.UserRepository.FindByID(ctx, id)
+}
+
+func (r *countingUsers) FindByIDs(ctx context.Context, ids []uint) ([]entity.User, error) {
+	r.calls++
+	return r.UserRepository.FindByIDs(ctx, ids)
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"MyGramAPI/app/repository"
+	"MyGramAPI/pkg/attempts"
+	"MyGramAPI/pkg/config"
+	"MyGramAPI/pkg/mail"
+	"MyGramAPI/pkg/openid"
+	"MyGramAPI/pkg/revocation"
+	"MyGramAPI/pkg/storage"
+	"time"
+)
+
+// Service holds the dependencies shared by every handler
+type Service struct {
+	Users         repository.UserRepository
+	Photos        repository.PhotoRepository
+	Comments      repository.CommentRepository
+	SocialMedia   repository.SocialMediaRepository
+	RefreshTokens repository.RefreshTokenRepository
+	UserTokens    repository.UserTokenRepository
+	RecoveryCodes repository.RecoveryCodeRepository
+	AuditLogs     repository.AuditLogRepository
+	AccessTokens  repository.PersonalAccessTokenRepository
+	Sessions      repository.SessionRepository
+	Media         storage.MediaStore
+	Revocations   revocation.Store
+	LoginAttempts attempts.Store
+	Mailer        mail.Mailer
+	// OIDC is nil when the OpenID Connect login isn't configured
+	OIDC *openid.Provider
+
+	AccessTokenTTL   time.Duration
+	RefreshTokenTTL  time.Duration
+	PasswordResetURL string
+	PasswordResetTTL time.Duration
+	VerificationURL  string
+	VerificationTTL  time.Duration
+	TOTPIssuer       string
+	ChallengeTTL     time.Duration
+	UnlockURL        string
+	AccountPolicy    attempts.Policy
+	IPPolicy         attempts.Policy
+	// ResetEmailPolicy and ResetIPPolicy limit the password reset requests, they count every request
+	ResetEmailPolicy attempts.Policy
+	ResetIPPolicy    attempts.Policy
+	OIDCRedirectURL  string
+	OIDCLoginTTL     time.Duration
+}
+
+// New returns a Service that stores its data in the given repositories, the uploads in the media store
+// the logged out tokens in the revocation store and the failed logins in the attempt store,
+// the mails of the account flows are sent by the mailer
+func New(repos repository.Repositories, media storage.MediaStore, revocations revocation.Store, loginAttempts attempts.Store, mailer mail.Mailer, cfg config.Config) *Service {
+	return &Service{
+		Users:         repos.Users,
+		Photos:        repos.Photos,
+		Comments:      repos.Comments,
+		SocialMedia:   repos.SocialMedia,
+		RefreshTokens: repos.RefreshTokens,
+		UserTokens:    repos.UserTokens,
+		RecoveryCodes: repos.RecoveryCodes,
+		AuditLogs:     repos.AuditLogs,
+		AccessTokens:  repos.AccessTokens,
+		Sessions:      repos.Sessions,
+		Media:         media,
+		Revocations:   revocations,
+		LoginAttempts: loginAttempts,
+		Mailer:        mailer,
+		OIDC:          openid.New(cfg.OIDC),
+
+		AccessTokenTTL:   time.Duration(cfg.JWT.AccessTokenTTL),
+		RefreshTokenTTL:  time.Duration(cfg.JWT.RefreshTokenTTL),
+		PasswordResetURL: cfg.Auth.PasswordResetURL,
+		PasswordResetTTL: time.Duration(cfg.Auth.PasswordResetTTL),
+		VerificationURL:  cfg.Auth.EmailVerificationURL,
+		VerificationTTL:  time.Duration(cfg.Auth.EmailVerificationTTL),
+		TOTPIssuer:       cfg.Auth.TOTPIssuer,
+		ChallengeTTL:     time.Duration(cfg.Auth.LoginChallengeTTL),
+		UnlockURL:        cfg.Auth.AccountUnlockURL,
+		AccountPolicy: attempts.Policy{
+			FreeAttempts:    cfg.Auth.AccountFreeAttempts,
+			BaseDelay:       time.Duration(cfg.Auth.LoginBackoff),
+			MaxDelay:        time.Duration(cfg.Auth.LoginMaxBackoff),
+			LockoutAttempts: cfg.Auth.AccountLockoutAttempts,
+			LockoutDuration: time.Duration(cfg.Auth.LockoutDuration),
+		},
+		IPPolicy: attempts.Policy{
+			FreeAttempts:    cfg.Auth.IPFreeAttempts,
+			BaseDelay:       time.Duration(cfg.Auth.LoginBackoff),
+			MaxDelay:        time.Duration(cfg.Auth.LoginMaxBackoff),
+			LockoutAttempts: cfg.Auth.IPLockoutAttempts,
+			LockoutDuration: time.Duration(cfg.Auth.LockoutDuration),
+		},
+		ResetEmailPolicy: attempts.Policy{
+			FreeAttempts:    cfg.Auth.PasswordResetEmailLimit,
+			LockoutAttempts: cfg.Auth.PasswordResetEmailLimit,
+			LockoutDuration: time.Duration(cfg.Auth.PasswordResetWindow),
+		},
+		ResetIPPolicy: attempts.Policy{
+			FreeAttempts:    cfg.Auth.PasswordResetIPLimit,
+			LockoutAttempts: cfg.Auth.PasswordResetIPLimit,
+			LockoutDuration: time.Duration(cfg.Auth.PasswordResetWindow),
+		},
+		OIDCRedirectURL: cfg.OIDC.RedirectURL,
+		OIDCLoginTTL:    time.Duration(cfg.OIDC.LoginTTL),
+	}
+}
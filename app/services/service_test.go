@@ -0,0 +1,122 @@
+package services
+
+import (
+	"MyGramAPI/app/entity"
+	"MyGramAPI/app/middleware"
+	"MyGramAPI/app/repository"
+	"MyGramAPI/pkg/attempts"
+	"MyGramAPI/pkg/config"
+	"MyGramAPI/pkg/helpers"
+	"MyGramAPI/pkg/mail"
+	"MyGramAPI/pkg/revocation"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	netmail "net/mail"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// testConfig returns the defaults completed with the settings that have none
+func testConfig() config.Config {
+	cfg := config.Default()
+	cfg.Database.DSN = "sqlite://mygram.db"
+	cfg.JWT.Secret = "secret"
+	cfg.Storage.Driver = "local"
+	return cfg
+}
+
+// newTestService returns a service on the memory stores, its mails are written to the returned directory
+func newTestService(t *testing.T, cfg config.Config) (*Service, string) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	if err := helpers.InitJWT(cfg.JWT); err != nil {
+		t.Fatal(err)
+	}
+	if err := helpers.InitPasswords(cfg.Password); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	mailer, err := mail.NewFile(dir, &netmail.Address{Address: "no-reply@mygram.example"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return New(repository.NewMemory(), nil, revocation.NewMemory(), attempts.NewMemory(), mailer, cfg), dir
+}
+
+// serve sends the request to the handlers of the route behind the error middleware, like the router does
+func serve(route string, request *http.Request, handlers ...gin.HandlerFunc) *httptest.ResponseRecorder {
+	router := gin.New()
+	router.Use(middleware.Errors())
+	router.Handle(request.Method, route, handlers...)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+// jsonRequest returns a request with the JSON body, the remote address is the one of a client in the tests
+func jsonRequest(method, target, body string) *http.Request {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	request := httptest.NewRequest(method, target, reader)
+	if body != "" {
+		request.Header.Set("Content-Type", appJSON)
+	}
+	request.RemoteAddr = "203.0.113.7:4321"
+	return request
+}
+
+// as sets the claims of the user like the authentication middleware does
+func as(userID uint) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("userData", helpers.AccessTokenClaims(userID, nil))
+	}
+}
+
+// testResponse is entity.Response with the data and details left to decode
+type testResponse struct {
+	Success bool
+	Message string
+	Data    json.RawMessage
+	Meta    *entity.Pagination
+	Error   *struct {
+		Code    string
+		Details json.RawMessage
+	}
+}
+
+// decode reads the response, its data is decoded into data unless it's nil
+func decode(t *testing.T, recorder *httptest.ResponseRecorder, data interface{}) testResponse {
+	t.Helper()
+
+	response := testResponse{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("the response isn't JSON: %v: %s", err, recorder.Body)
+	}
+	if data != nil {
+		if err := json.Unmarshal(response.Data, data); err != nil {
+			t.Fatalf("unexpected data: %v: %s", err, response.Data)
+		}
+	}
+	return response
+}
+
+// createUser registers a user with the password secret123, its email is the username at example.com
+func createUser(t *testing.T, s *Service, username string) entity.User {
+	t.Helper()
+
+	User := entity.User{Username: username, Email: username + "@example.com", Password: "secret123", Age: 20}
+	if err := s.Users.Create(context.Background(), &User); err != nil {
+		t.Fatal(err)
+	}
+	return User
+}
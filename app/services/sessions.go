@@ -0,0 +1,89 @@
+package services
+
+import (
+	"MyGramAPI/app/entity"
+	"MyGramAPI/app/repository"
+	"MyGramAPI/pkg/helpers"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UserListSessions godoc
+// @Summary List the sessions
+// @Description List the devices the user is logged in on, last seen first, with their user agent and the IP they were last seen from. current is true for the session of the request
+// @Tags users
+// @Produce json
+// @Success 200 {object} entity.Response "The sessions of the user"
+// @Failure 401  {object}  entity.Response "If you are not logged in"
+// @Failure 403  {object}  entity.Response "If the request is made with a personal access token"
+// @Security Bearer
+// @Router /api/v1/users/me/sessions [get]
+func (s *Service) UserListSessions(c *gin.Context) {
+	userData := c.MustGet("userData").(*helpers.Claims)
+
+	Session, err := s.Sessions.FindByUserID(c.Request.Context(), userData.UserID())
+	if err != nil {
+		c.Error(repositoryError(err, "Session"))
+		return
+	}
+
+	ResData := []entity.DataSession{}
+	for _, session := range Session {
+		ResData = append(ResData, entity.DataSession{
+			ID:         session.ID,
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			Current:    session.ID == userData.SessionID,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, entity.Response{
+		Success: true,
+		Message: "Sessions has been loaded successfully",
+		Data:    ResData,
+	})
+}
+
+// UserRemoveSession godoc
+// @Summary Log out a session
+// @Description Log the device of the session out, its access token and refresh token are rejected right away. Removing the current session logs you out
+// @Tags users
+// @Produce json
+// @Param id path int true "session id"
+// @Success 200 {object} entity.Response "If the session is removed"
+// @Failure 401  {object}  entity.Response "If you are not logged in"
+// @Failure 403  {object}  entity.Response "If the request is made with a personal access token"
+// @Failure 404  {object}  entity.Response "If you have no session with the id"
+// @Security Bearer
+// @Router /api/v1/users/me/sessions/{id} [delete]
+func (s *Service) UserRemoveSession(c *gin.Context) {
+	ctx := c.Request.Context()
+	userData := c.MustGet("userData").(*helpers.Claims)
+	sessionID, _ := strconv.Atoi(c.Param("id"))
+
+	Session, err := s.Sessions.FindByID(ctx, uint(sessionID))
+	if err == nil && Session.UserID != userData.UserID() {
+		// the sessions of someone else look like missing ones
+		c.Error(repositoryError(repository.ErrNotFound, "Session"))
+		return
+	}
+	if err != nil {
+		c.Error(repositoryError(err, "Session"))
+		return
+	}
+
+	if err := s.endSession(ctx, Session); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entity.Response{
+		Success: true,
+		Message: "Session has been logged out",
+		Data:    nil,
+	})
+}
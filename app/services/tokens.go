@@ -0,0 +1,256 @@
+package services
+
+import (
+	"MyGramAPI/app/apperror"
+	"MyGramAPI/app/entity"
+	"MyGramAPI/app/repository"
+	"MyGramAPI/pkg/helpers"
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// startSession records the login of the user on the device and returns its first tokens
+func (s *Service) startSession(ctx context.Context, user entity.User, userAgent, ip string) (entity.DataLogin, error) {
+	Session := entity.Session{
+		UserID:     user.ID,
+		FamilyID:   uuid.New().String(),
+		UserAgent:  userAgent,
+		IP:         ip,
+		LastSeenAt: time.Now(),
+	}
+	if err := s.Sessions.Create(ctx, &Session); err != nil {
+		return entity.DataLogin{}, repositoryError(err, "Session")
+	}
+
+	return s.issueTokens(ctx, user, Session)
+}
+
+// issueTokens returns a new access token of the session and the next refresh token of its family
+func (s *Service) issueTokens(ctx context.Context, user entity.User, session entity.Session) (entity.DataLogin, error) {
+	token, hash, err := helpers.RandomToken()
+	if err != nil {
+		return entity.DataLogin{}, apperror.Internal(err)
+	}
+
+	RefreshToken := entity.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  session.FamilyID,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(s.RefreshTokenTTL),
+	}
+	if err := s.RefreshTokens.Create(ctx, &RefreshToken); err != nil {
+		return entity.DataLogin{}, repositoryError(err, "Refresh token")
+	}
+
+	return entity.DataLogin{
+		Token:        helpers.GenerateToken(user.ID, session.ID, user.Email, user.CreatedAt),
+		ExpiresIn:    int64(s.AccessTokenTTL / time.Second),
+		RefreshToken: token,
+	}, nil
+}
+
+// rotateRefreshToken exchanges a refresh token for new tokens, the old one can't be used again.
+// A token that is used twice was stolen by someone, so its whole family is revoked.
+func (s *Service) rotateRefreshToken(ctx context.Context, token, userAgent, ip string) (entity.DataLogin, error) {
+	RefreshToken, err := s.RefreshTokens.FindByHash(ctx, helpers.HashToken(token))
+	if errors.Is(err, repository.ErrNotFound) {
+		return entity.DataLogin{}, apperror.Unauthorized("Invalid refresh token")
+	}
+	if err != nil {
+		return entity.DataLogin{}, repositoryError(err, "Refresh token")
+	}
+
+	if RefreshToken.RevokedAt != nil {
+		return entity.DataLogin{}, apperror.Unauthorized("Refresh token has been revoked, please log in again")
+	}
+	if RefreshToken.UsedAt != nil {
+		return entity.DataLogin{}, s.refreshTokenReused(ctx, RefreshToken)
+	}
+	if time.Now().After(RefreshToken.ExpiresAt) {
+		return entity.DataLogin{}, apperror.Unauthorized("Refresh token has expired, please log in again")
+	}
+
+	err = s.RefreshTokens.MarkUsed(ctx, RefreshToken.ID)
+	if errors.Is(err, repository.ErrConflict) {
+		return entity.DataLogin{}, s.refreshTokenReused(ctx, RefreshToken)
+	}
+	if err != nil {
+		return entity.DataLogin{}, repositoryError(err, "Refresh token")
+	}
+
+	User, err := s.Users.FindByID(ctx, RefreshToken.UserID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return entity.DataLogin{}, apperror.Unauthorized("Invalid refresh token")
+	}
+	if err != nil {
+		return entity.DataLogin{}, repositoryError(err, "User")
+	}
+
+	now := time.Now()
+	Session, err := s.Sessions.FindByFamily(ctx, RefreshToken.FamilyID)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		// a removed session has its family revoked, so this is a login made before the sessions were recorded
+		Session = entity.Session{UserID: User.ID, FamilyID: RefreshToken.FamilyID, UserAgent: userAgent, IP: ip, LastSeenAt: now}
+		err = s.Sessions.Create(ctx, &Session)
+	case err == nil:
+		err = s.Sessions.Touch(ctx, Session.ID, now, ip)
+	}
+	if err != nil {
+		return entity.DataLogin{}, repositoryError(err, "Session")
+	}
+
+	return s.issueTokens(ctx, User, Session)
+}
+
+// refreshTokenReused revokes every token of the family of a replayed token
+func (s *Service) refreshTokenReused(ctx context.Context, token entity.RefreshToken) error {
+	log.Printf("refresh token %d of user %d was used twice, revoking its family %s", token.ID, token.UserID, token.FamilyID)
+
+	Session, err := s.Sessions.FindByFamily(ctx, token.FamilyID)
+	if errors.Is(err, repository.ErrNotFound) {
+		Session = entity.Session{UserID: token.UserID, FamilyID: token.FamilyID}
+	} else if err != nil {
+		return repositoryError(err, "Session")
+	}
+
+	// the access tokens of the session may be stolen too, so the session ends with the family
+	if err := s.endSession(ctx, Session); err != nil {
+		return err
+	}
+	return apperror.Unauthorized("Refresh token has already been used, please log in again")
+}
+
+// endSession revokes the refresh token family of the session and removes it, which rejects its access tokens.
+// A session without id only has its family revoked.
+func (s *Service) endSession(ctx context.Context, session entity.Session) error {
+	if err := s.RefreshTokens.RevokeFamily(ctx, session.FamilyID); err != nil {
+		return repositoryError(err, "Refresh token")
+	}
+
+	if session.ID == 0 {
+		return nil
+	}
+	if err := s.Sessions.Delete(ctx, session.UserID, session.ID); err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return repositoryError(err, "Session")
+	}
+	return nil
+}
+
+// logout revokes the access token and ends its session, the family of the refresh token sent with it is revoked too.
+// A refresh token of another user is ignored.
+func (s *Service) logout(ctx context.Context, userID, sessionID uint, jti string, expiresAt time.Time, refreshToken string) error {
+	if err := s.Revocations.Revoke(ctx, jti, userID, expiresAt); err != nil {
+		return apperror.Unavailable("Token revocation store is unavailable, please try again later", err)
+	}
+
+	Session, err := s.Sessions.FindByID(ctx, sessionID)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return repositoryError(err, "Session")
+	}
+	if err == nil && Session.UserID == userID {
+		if err := s.endSession(ctx, Session); err != nil {
+			return err
+		}
+	}
+
+	if refreshToken == "" {
+		return nil
+	}
+
+	RefreshToken, err := s.RefreshTokens.FindByHash(ctx, helpers.HashToken(refreshToken))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return repositoryError(err, "Refresh token")
+	}
+	if RefreshToken.UserID != userID {
+		return nil
+	}
+
+	if err := s.RefreshTokens.RevokeFamily(ctx, RefreshToken.FamilyID); err != nil {
+		return repositoryError(err, "Refresh token")
+	}
+	return nil
+}
+
+// logoutAll revokes every access token and refresh token the user has been issued so far
+func (s *Service) logoutAll(ctx context.Context, userID uint) error {
+	if err := s.Revocations.RevokeUser(ctx, userID, time.Now()); err != nil {
+		return apperror.Unavailable("Token revocation store is unavailable, please try again later", err)
+	}
+
+	if err := s.RefreshTokens.RevokeUser(ctx, userID); err != nil {
+		return repositoryError(err, "Refresh token")
+	}
+
+	if err := s.Sessions.DeleteUser(ctx, userID); err != nil {
+		return repositoryError(err, "Session")
+	}
+	return nil
+}
+
+// issueUserToken returns a new one-time token for the purpose, the previous unused tokens of the purpose stop working
+func (s *Service) issueUserToken(ctx context.Context, userID uint, purpose string, ttl time.Duration) (string, error) {
+	token, hash, err := helpers.RandomToken()
+	if err != nil {
+		return "", apperror.Internal(err)
+	}
+
+	if err := s.UserTokens.Discard(ctx, userID, purpose); err != nil {
+		return "", repositoryError(err, "Token")
+	}
+
+	UserToken := entity.UserToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if err := s.UserTokens.Create(ctx, &UserToken); err != nil {
+		return "", repositoryError(err, "Token")
+	}
+	return token, nil
+}
+
+// findUserToken returns a one-time token that can still be used, an unknown, used or expired token returns the invalid error
+func (s *Service) findUserToken(ctx context.Context, purpose, token string, invalid error) (entity.UserToken, error) {
+	UserToken, err := s.UserTokens.FindByHash(ctx, purpose, helpers.HashToken(token))
+	if errors.Is(err, repository.ErrNotFound) {
+		return entity.UserToken{}, invalid
+	}
+	if err != nil {
+		return entity.UserToken{}, repositoryError(err, "Token")
+	}
+
+	if UserToken.UsedAt != nil || time.Now().After(UserToken.ExpiresAt) {
+		return entity.UserToken{}, invalid
+	}
+	return UserToken, nil
+}
+
+// consumeUserToken marks a token returned by findUserToken as used, a token used meanwhile returns the invalid error
+func (s *Service) consumeUserToken(ctx context.Context, token entity.UserToken, invalid error) error {
+	err := s.UserTokens.MarkUsed(ctx, token.ID)
+	if errors.Is(err, repository.ErrConflict) {
+		return invalid
+	}
+	if err != nil {
+		return repositoryError(err, "Token")
+	}
+	return nil
+}
+
+// useUserToken finds and consumes a one-time token
+func (s *Service) useUserToken(ctx context.Context, purpose, token string, invalid error) (entity.UserToken, error) {
+	UserToken, err := s.findUserToken(ctx, purpose, token, invalid)
+	if err != nil {
+		return entity.UserToken{}, err
+	}
+	return UserToken, s.consumeUserToken(ctx, UserToken, invalid)
+}
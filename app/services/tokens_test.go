@@ -0,0 +1,126 @@
+package services
+
+import (
+	"MyGramAPI/app/entity"
+	"MyGramAPI/app/middleware"
+	"MyGramAPI/pkg/helpers"
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestUserRefreshRotatesTheTokens(t *testing.T) {
+	s, _ := newTestService(t, testConfig())
+	createUser(t, s, "alice")
+
+	first := login(t, s, "alice@example.com")
+	second := refresh(t, s, first.RefreshToken, http.StatusOK)
+	if second.RefreshToken == "" || second.RefreshToken == first.RefreshToken || second.Token == "" {
+		t.Fatalf("got tokens %+v, want a new pair", second)
+	}
+	if status := authenticate(s, second.Token); status != http.StatusNoContent {
+		t.Errorf("new access token: got status %d, want 204", status)
+	}
+
+	// the next rotation takes the new refresh token
+	third := refresh(t, s, second.RefreshToken, http.StatusOK)
+	if third.RefreshToken == second.RefreshToken {
+		t.Errorf("got the same refresh token twice")
+	}
+
+	// a rotated token is never accepted again
+	refresh(t, s, first.RefreshToken, http.StatusUnauthorized)
+}
+
+func TestUserRefreshRejectsAnExpiredToken(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestService(t, testConfig())
+	Alice := createUser(t, s, "alice")
+
+	token, hash, err := helpers.RandomToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	RefreshToken := entity.RefreshToken{UserID: Alice.ID, FamilyID: "family", TokenHash: hash, ExpiresAt: time.Now().Add(-time.Minute)}
+	if err := s.RefreshTokens.Create(ctx, &RefreshToken); err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := serve("/api/v1/users/refresh", jsonRequest(http.MethodPost, "/api/v1/users/refresh", `{"refresh_token":"`+token+`"}`), s.UserRefresh)
+	if recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("got status %d, want 401: %s", recorder.Code, recorder.Body)
+	}
+	if message := decode(t, recorder, nil).Message; message != "Refresh token has expired, please log in again" {
+		t.Errorf("got message %q, want the expiry", message)
+	}
+
+	refresh(t, s, "unknown", http.StatusUnauthorized)
+}
+
+func TestUserRefreshReplayRevokesTheFamily(t *testing.T) {
+	s, _ := newTestService(t, testConfig())
+	createUser(t, s, "alice")
+
+	// another login of alice isn't touched by the replay
+	other := login(t, s, "alice@example.com")
+
+	first := login(t, s, "alice@example.com")
+	second := refresh(t, s, first.RefreshToken, http.StatusOK)
+
+	// whoever replays the used token can't get new tokens, and neither can the holder of the rotated ones
+	refresh(t, s, first.RefreshToken, http.StatusUnauthorized)
+	if status := authenticate(s, second.Token); status != http.StatusUnauthorized {
+		t.Errorf("access token of the ended session: got status %d, want 401", status)
+	}
+	refresh(t, s, second.RefreshToken, http.StatusUnauthorized)
+
+	if status := authenticate(s, other.Token); status != http.StatusNoContent {
+		t.Errorf("access token of the other session: got status %d, want 204", status)
+	}
+	refresh(t, s, other.RefreshToken, http.StatusOK)
+}
+
+// login logs the user in with the password secret123 and returns its tokens
+func login(t *testing.T, s *Service, email string) entity.DataLogin {
+	t.Helper()
+
+	recorder := serve("/api/v1/users/login", jsonRequest(http.MethodPost, "/api/v1/users/login", `{"email":"`+email+`","password":"secret123"}`), s.UserLogin)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("login of %s: got status %d, want 200: %s", email, recorder.Code, recorder.Body)
+	}
+
+	DataLogin := entity.DataLogin{}
+	decode(t, recorder, &DataLogin)
+	return DataLogin
+}
+
+// refresh exchanges the refresh token, the response must have the status
+func refresh(t *testing.T, s *Service, token string, status int) entity.DataLogin {
+	t.Helper()
+
+	recorder := serve("/api/v1/users/refresh", jsonRequest(http.MethodPost, "/api/v1/users/refresh", `{"refresh_token":"`+token+`"}`), s.UserRefresh)
+	if recorder.Code != status {
+		t.Fatalf("refresh: got status %d, want %d: %s", recorder.Code, status, recorder.Body)
+	}
+
+	DataLogin := entity.DataLogin{}
+	if status == http.StatusOK {
+		decode(t, recorder, &DataLogin)
+	}
+	return DataLogin
+}
+
+// authenticate returns the status of a request made with the access token to a route that needs a login
+func authenticate(s *Service, token string) int {
+	request := jsonRequest(http.MethodGet, "/api/v1/users/sessions", "")
+	request.Header.Set("Authorization", "Bearer "+token)
+
+	recorder := serve("/api/v1/users/sessions", request,
+		middleware.Authentication(s.Revocations, s.AccessTokens, s.Sessions),
+		func(c *gin.Context) { c.Status(http.StatusNoContent) },
+	)
+	return recorder.Code
+}
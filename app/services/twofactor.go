@@ -0,0 +1,419 @@
+package services
+
+import (
+	"MyGramAPI/app/apperror"
+	"MyGramAPI/app/entity"
+	"MyGramAPI/app/repository"
+	"MyGramAPI/pkg/helpers"
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// recoveryCodeCount is how many recovery codes are generated at once
+const recoveryCodeCount = 10
+
+// UserEnrollTOTP godoc
+// @Summary Enroll in two-factor authentication
+// @Description Generate a TOTP secret for your authenticator app. Add it with the otpauth URI or by scanning the QR code, then confirm it with a code. Enrolling again replaces a secret that isn't confirmed yet
+// @Tags users
+// @Produce json
+// @Success 200 {object} entity.Response "The secret, its otpauth URI and its QR code"
+// @Failure 401  {object}  entity.Response "If you are not logged in"
+// @Failure 409  {object}  entity.Response "If two-factor authentication is already enabled"
+// @Security Bearer
+// @Router /api/v1/users/2fa/enroll [post]
+func (s *Service) UserEnrollTOTP(c *gin.Context) {
+	userData := c.MustGet("userData").(*helpers.Claims)
+	ctx := c.Request.Context()
+
+	User, err := s.Users.FindByID(ctx, userData.UserID())
+	if err != nil {
+		c.Error(repositoryError(err, "User"))
+		return
+	}
+
+	if User.TOTPEnabled {
+		c.Error(apperror.Conflict("Two-factor authentication is already enabled"))
+		return
+	}
+
+	key, err := helpers.GenerateTOTP(s.TOTPIssuer, User.Email)
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	qrCode, err := helpers.QRCode(key)
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	if err := s.Users.UpdateTOTP(ctx, User.ID, key.Secret(), false); err != nil {
+		c.Error(repositoryError(err, "User"))
+		return
+	}
+
+	c.JSON(http.StatusOK, entity.Response{
+		Success: true,
+		Message: "Add the secret to your authenticator app, then confirm it with a code",
+		Data: entity.DataTOTPEnrollment{
+			Secret: key.Secret(),
+			URI:    key.URL(),
+			QRCode: qrCode,
+		},
+	})
+}
+
+// UserConfirmTOTP godoc
+// @Summary Confirm two-factor authentication
+// @Description Enable two-factor authentication with a code of the authenticator app. The recovery codes are only shown in this response, each one can replace a code once
+// @Tags users
+// @Consumes ({mpfd,json})
+// @Produce json
+// @Param code formData string true "code of the authenticator app"
+// @Success 200 {object} entity.Response "If the code is valid, two-factor authentication is enabled"
+// @Failure 400  {object}  entity.Response "If the code is invalid or you haven't enrolled"
+// @Failure 401  {object}  entity.Response "If you are not logged in"
+// @Failure 409  {object}  entity.Response "If two-factor authentication is already enabled"
+// @Security Bearer
+// @Router /api/v1/users/2fa/confirm [post]
+func (s *Service) UserConfirmTOTP(c *gin.Context) {
+	userData := c.MustGet("userData").(*helpers.Claims)
+	ctx := c.Request.Context()
+
+	Code, ok := bindCode(c)
+	if !ok {
+		return
+	}
+
+	User, err := s.Users.FindByID(ctx, userData.UserID())
+	if err != nil {
+		c.Error(repositoryError(err, "User"))
+		return
+	}
+
+	if User.TOTPEnabled {
+		c.Error(apperror.Conflict("Two-factor authentication is already enabled"))
+		return
+	}
+	if User.TOTPSecret == "" {
+		c.Error(apperror.Validation("Enroll in two-factor authentication first", nil))
+		return
+	}
+	valid, err := s.useTOTPCode(ctx, User, Code.Code)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if !valid {
+		c.Error(apperror.Validation("Invalid code", nil))
+		return
+	}
+
+	if err := s.Users.UpdateTOTP(ctx, User.ID, User.TOTPSecret, true); err != nil {
+		c.Error(repositoryError(err, "User"))
+		return
+	}
+
+	DataRecoveryCodes, err := s.newRecoveryCodes(ctx, User.ID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entity.Response{
+		Success: true,
+		Message: "Two-factor authentication has been enabled, keep the recovery codes somewhere safe",
+		Data:    DataRecoveryCodes,
+	})
+}
+
+// UserRegenerateRecoveryCodes godoc
+// @Summary Generate new recovery codes
+// @Description Replace your recovery codes, the previous ones stop working. The new codes are only shown in this response
+// @Tags users
+// @Consumes ({mpfd,json})
+// @Produce json
+// @Param code formData string true "code of the authenticator app"
+// @Success 200 {object} entity.Response "If the code is valid, the new recovery codes"
+// @Failure 400  {object}  entity.Response "If the code is invalid"
+// @Failure 401  {object}  entity.Response "If you are not logged in"
+// @Failure 409  {object}  entity.Response "If two-factor authentication isn't enabled"
+// @Security Bearer
+// @Router /api/v1/users/2fa/recovery-codes [post]
+func (s *Service) UserRegenerateRecoveryCodes(c *gin.Context) {
+	userData := c.MustGet("userData").(*helpers.Claims)
+	ctx := c.Request.Context()
+
+	Code, ok := bindCode(c)
+	if !ok {
+		return
+	}
+
+	User, err := s.twoFactorUser(ctx, userData.UserID())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	// a recovery code can't be traded for new ones, it would never run out
+	valid, err := s.useTOTPCode(ctx, User, Code.Code)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if !valid {
+		c.Error(apperror.Validation("Invalid code", nil))
+		return
+	}
+
+	DataRecoveryCodes, err := s.newRecoveryCodes(ctx, User.ID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entity.Response{
+		Success: true,
+		Message: "Recovery codes have been replaced, keep them somewhere safe",
+		Data:    DataRecoveryCodes,
+	})
+}
+
+// UserDisableTOTP godoc
+// @Summary Disable two-factor authentication
+// @Description Remove the TOTP secret and the recovery codes, the login only asks for the password again
+// @Tags users
+// @Consumes ({mpfd,json})
+// @Produce json
+// @Param code formData string true "code of the authenticator app or a recovery code"
+// @Success 200 {object} entity.Response "If the code is valid, two-factor authentication is disabled"
+// @Failure 400  {object}  entity.Response "If the code is invalid"
+// @Failure 401  {object}  entity.Response "If you are not logged in"
+// @Failure 409  {object}  entity.Response "If two-factor authentication isn't enabled"
+// @Security Bearer
+// @Router /api/v1/users/2fa/disable [post]
+func (s *Service) UserDisableTOTP(c *gin.Context) {
+	userData := c.MustGet("userData").(*helpers.Claims)
+	ctx := c.Request.Context()
+
+	Code, ok := bindCode(c)
+	if !ok {
+		return
+	}
+
+	User, err := s.twoFactorUser(ctx, userData.UserID())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	valid, err := s.checkSecondFactor(ctx, User, Code.Code)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if !valid {
+		c.Error(apperror.Validation("Invalid code", nil))
+		return
+	}
+
+	if err := s.Users.UpdateTOTP(ctx, User.ID, "", false); err != nil {
+		c.Error(repositoryError(err, "User"))
+		return
+	}
+	if err := s.RecoveryCodes.Replace(ctx, User.ID, nil); err != nil {
+		c.Error(repositoryError(err, "Recovery code"))
+		return
+	}
+
+	c.JSON(http.StatusOK, entity.Response{
+		Success: true,
+		Message: "Two-factor authentication has been disabled",
+		Data:    nil,
+	})
+}
+
+// UserLoginChallenge godoc
+// @Summary User Login, second step
+// @Description Finish the login of an account with two-factor authentication. Send the challenge token returned by the login with a code of the authenticator app or a recovery code. Each code works once
+// @Tags users
+// @Consumes ({mpfd,json})
+// @Produce json
+// @Param challenge_token formData string true "challenge token returned by the login"
+// @Param code formData string true "code of the authenticator app or a recovery code"
+// @Success 200 {object} entity.Response "If the code is valid, you will get an access token and a refresh token"
+// @Failure 400  {object}  entity.Response "If some fields are missing, every invalid field is listed in error.details"
+// @Failure 401  {object}  entity.Response "If the challenge has expired or the code is invalid"
+// @Failure 429  {object}  entity.Response "If there were too many failed logins, wait for the Retry-After header or unlock the account with the mailed link"
+// @Router /api/v1/users/login/2fa [post]
+func (s *Service) UserLoginChallenge(c *gin.Context) {
+	ctx := c.Request.Context()
+	contentType := helpers.GetContentType(c)
+	Challenge := entity.LoginChallenge{}
+
+	if contentType == appJSON {
+		c.ShouldBindJSON(&Challenge)
+	} else {
+		c.ShouldBind(&Challenge)
+	}
+
+	if fields := validate(&Challenge); len(fields) > 0 {
+		c.Error(validationError(fields))
+		return
+	}
+
+	expired := apperror.Unauthorized("Login has expired, please log in again")
+
+	// a wrong code doesn't use the challenge, so a typo doesn't need the password again
+	UserToken, err := s.findUserToken(ctx, entity.PurposeLoginChallenge, Challenge.ChallengeToken, expired)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	User, err := s.Users.FindByID(ctx, UserToken.UserID)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.Error(expired)
+		return
+	}
+	if err != nil {
+		c.Error(repositoryError(err, "User"))
+		return
+	}
+
+	// the codes are guessed more easily than the password, so they share its failed login count
+	ip := c.ClientIP()
+	if err := s.checkLoginAttempts(ctx, User.Email, ip); err != nil {
+		c.Error(err)
+		return
+	}
+
+	valid, err := s.checkSecondFactor(ctx, User, Challenge.Code)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if !valid {
+		s.loginFailed(ctx, User.Email, ip, &User)
+		c.Error(apperror.Unauthorized("Invalid code"))
+		return
+	}
+
+	if err := s.consumeUserToken(ctx, UserToken, expired); err != nil {
+		c.Error(err)
+		return
+	}
+	s.loginSucceeded(ctx, User.Email)
+
+	DataLogin, err := s.startSession(ctx, User, c.Request.UserAgent(), ip)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entity.Response{
+		Success: true,
+		Message: "User logged in successfully",
+		Data:    DataLogin,
+	})
+}
+
+// loginChallenge starts the second step of the login of a user with two-factor authentication
+func (s *Service) loginChallenge(ctx context.Context, user entity.User) (entity.DataChallenge, error) {
+	token, err := s.issueUserToken(ctx, user.ID, entity.PurposeLoginChallenge, s.ChallengeTTL)
+	if err != nil {
+		return entity.DataChallenge{}, err
+	}
+
+	return entity.DataChallenge{
+		MFARequired:    true,
+		ChallengeToken: token,
+		ExpiresIn:      int64(s.ChallengeTTL.Seconds()),
+	}, nil
+}
+
+// checkSecondFactor reports whether the code is a valid TOTP code of the user or one of their unused recovery codes
+func (s *Service) checkSecondFactor(ctx context.Context, user entity.User, code string) (bool, error) {
+	if !user.TOTPEnabled {
+		return false, nil
+	}
+	if valid, err := s.useTOTPCode(ctx, user, code); valid || err != nil {
+		return valid, err
+	}
+
+	err := s.RecoveryCodes.Use(ctx, user.ID, helpers.HashRecoveryCode(code))
+	if errors.Is(err, repository.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, repositoryError(err, "Recovery code")
+	}
+	return true, nil
+}
+
+// useTOTPCode reports whether the code is a valid TOTP code of the user that wasn't used yet,
+// a valid code also uses up the codes of its time step and the earlier ones
+func (s *Service) useTOTPCode(ctx context.Context, user entity.User, code string) (bool, error) {
+	step, valid := helpers.ValidateTOTP(code, user.TOTPSecret)
+	if !valid || step <= user.TOTPLastStep {
+		return false, nil
+	}
+
+	err := s.Users.UseTOTPStep(ctx, user.ID, step)
+	if errors.Is(err, repository.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, repositoryError(err, "User")
+	}
+	return true, nil
+}
+
+// newRecoveryCodes replaces the recovery codes of the user
+func (s *Service) newRecoveryCodes(ctx context.Context, userID uint) (entity.DataRecoveryCodes, error) {
+	codes, hashes, err := helpers.RecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return entity.DataRecoveryCodes{}, apperror.Internal(err)
+	}
+
+	if err := s.RecoveryCodes.Replace(ctx, userID, hashes); err != nil {
+		return entity.DataRecoveryCodes{}, repositoryError(err, "Recovery code")
+	}
+	return entity.DataRecoveryCodes{RecoveryCodes: codes}, nil
+}
+
+// twoFactorUser returns the user, a user without two-factor authentication is a conflict
+func (s *Service) twoFactorUser(ctx context.Context, userID uint) (entity.User, error) {
+	User, err := s.Users.FindByID(ctx, userID)
+	if err != nil {
+		return entity.User{}, repositoryError(err, "User")
+	}
+
+	if !User.TOTPEnabled {
+		return entity.User{}, apperror.Conflict("Two-factor authentication isn't enabled")
+	}
+	return User, nil
+}
+
+// bindCode binds and validates the code of the request, the error is already added when it returns false
+func bindCode(c *gin.Context) (entity.TOTPCode, bool) {
+	contentType := helpers.GetContentType(c)
+	Code := entity.TOTPCode{}
+
+	if contentType == appJSON {
+		c.ShouldBindJSON(&Code)
+	} else {
+		c.ShouldBind(&Code)
+	}
+
+	if fields := validate(&Code); len(fields) > 0 {
+		c.Error(validationError(fields))
+		return Code, false
+	}
+	return Code, true
+}
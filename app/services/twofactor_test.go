@@ -0,0 +1,149 @@
+package services
+
+import (
+	"MyGramAPI/app/entity"
+	"MyGramAPI/pkg/helpers"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+)
+
+func TestUserLoginChallengeAcceptsACodeOnce(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestService(t, testConfig())
+	Alice := createUser(t, s, "alice")
+	secret := enableTOTP(t, s, Alice)
+	now := time.Now()
+
+	if recorder := sendCode(s, challenge(t, s, "alice@example.com"), totpCode(t, secret, now)); recorder.Code != http.StatusOK {
+		t.Fatalf("first use of the code: got status %d, want 200: %s", recorder.Code, recorder.Body)
+	}
+
+	// whoever saw the code can't log in with it while it's still valid, nor with a wrong one
+	token := challenge(t, s, "alice@example.com")
+	for name, code := range map[string]string{"replayed code": totpCode(t, secret, now), "wrong code": totpCode(t, secret, now.Add(10*time.Minute))} {
+		recorder := sendCode(s, token, code)
+		if recorder.Code != http.StatusUnauthorized {
+			t.Fatalf("%s: got status %d, want 401: %s", name, recorder.Code, recorder.Body)
+		}
+		if message := decode(t, recorder, nil).Message; message != "Invalid code" {
+			t.Errorf("%s: got message %q, want Invalid code", name, message)
+		}
+	}
+	checkFailures(t, s, accountKey("alice@example.com"), 2)
+	checkFailures(t, s, ipKey("203.0.113.7"), 2)
+
+	// the challenge is still open, the code of the next step logs in and forgets the failures of the account
+	if recorder := sendCode(s, token, totpCode(t, secret, now.Add(30*time.Second))); recorder.Code != http.StatusOK {
+		t.Fatalf("code of the next step: got status %d, want 200: %s", recorder.Code, recorder.Body)
+	}
+	checkFailures(t, s, accountKey("alice@example.com"), 0)
+	checkFailures(t, s, ipKey("203.0.113.7"), 2)
+
+	// the code of an earlier step is still in the window, but a later one was used
+	if recorder := sendCode(s, challenge(t, s, "alice@example.com"), totpCode(t, secret, now.Add(-30*time.Second))); recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("code of the previous step: got status %d, want 401: %s", recorder.Code, recorder.Body)
+	}
+
+	User, err := s.Users.FindByID(ctx, Alice.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := now.Add(30*time.Second).Unix() / 30; User.TOTPLastStep != want {
+		t.Errorf("got last step %d, want %d", User.TOTPLastStep, want)
+	}
+}
+
+func TestUserLoginChallengeSharesTheFailedLoginCount(t *testing.T) {
+	s, _ := newTestService(t, testConfig())
+	Alice := createUser(t, s, "alice")
+	secret := enableTOTP(t, s, Alice)
+
+	// two wrong codes and a wrong password use up the free attempts of the account
+	token := challenge(t, s, "alice@example.com")
+	wrong := totpCode(t, secret, time.Now().Add(10*time.Minute))
+	for i := 0; i < 2; i++ {
+		if recorder := sendCode(s, token, wrong); recorder.Code != http.StatusUnauthorized {
+			t.Fatalf("wrong code: got status %d, want 401: %s", recorder.Code, recorder.Body)
+		}
+	}
+	recorder := serve("/api/v1/users/login", jsonRequest(http.MethodPost, "/api/v1/users/login", `{"email":"alice@example.com","password":"wrong"}`), s.UserLogin)
+	if recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong password: got status %d, want 401: %s", recorder.Code, recorder.Body)
+	}
+	checkFailures(t, s, accountKey("alice@example.com"), 3)
+
+	// both steps now wait, even with the right password or code
+	recorder = serve("/api/v1/users/login", jsonRequest(http.MethodPost, "/api/v1/users/login", `{"email":"alice@example.com","password":"secret123"}`), s.UserLogin)
+	if recorder.Code != http.StatusTooManyRequests {
+		t.Errorf("password after the failures: got status %d, want 429: %s", recorder.Code, recorder.Body)
+	}
+	if recorder := sendCode(s, token, totpCode(t, secret, time.Now())); recorder.Code != http.StatusTooManyRequests {
+		t.Errorf("code after the failures: got status %d, want 429: %s", recorder.Code, recorder.Body)
+	}
+}
+
+// enableTOTP turns two-factor authentication on for the user and returns its secret
+func enableTOTP(t *testing.T, s *Service, user entity.User) string {
+	t.Helper()
+
+	key, err := helpers.GenerateTOTP("MyGram", user.Email)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Users.UpdateTOTP(context.Background(), user.ID, key.Secret(), true); err != nil {
+		t.Fatal(err)
+	}
+	return key.Secret()
+}
+
+// totpCode returns the code of the secret at the time
+func totpCode(t *testing.T, secret string, at time.Time) string {
+	t.Helper()
+
+	code, err := totp.GenerateCode(secret, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+// challenge logs the user with two-factor authentication in with the password secret123 and returns the challenge token
+func challenge(t *testing.T, s *Service, email string) string {
+	t.Helper()
+
+	recorder := serve("/api/v1/users/login", jsonRequest(http.MethodPost, "/api/v1/users/login", `{"email":"`+email+`","password":"secret123"}`), s.UserLogin)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("login of %s: got status %d, want 200: %s", email, recorder.Code, recorder.Body)
+	}
+
+	DataChallenge := entity.DataChallenge{}
+	decode(t, recorder, &DataChallenge)
+	if !DataChallenge.MFARequired || DataChallenge.ChallengeToken == "" {
+		t.Fatalf("login of %s: got %+v, want a challenge", email, DataChallenge)
+	}
+	return DataChallenge.ChallengeToken
+}
+
+// sendCode finishes the login of the challenge with the code
+func sendCode(s *Service, token, code string) *httptest.ResponseRecorder {
+	request := jsonRequest(http.MethodPost, "/api/v1/users/login/2fa", `{"challenge_token":"`+token+`","code":"`+code+`"}`)
+	return serve("/api/v1/users/login/2fa", request, s.UserLoginChallenge)
+}
+
+// checkFailures checks the number of failed logins counted for the key
+func checkFailures(t *testing.T, s *Service, key string, failures int) {
+	t.Helper()
+
+	counter, err := s.LoginAttempts.Get(context.Background(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counter.Failures != failures {
+		t.Errorf("%s: got %d failures, want %d", key, counter.Failures, failures)
+	}
+}
@@ -1,129 +1,267 @@
-package services
-
-import (
-	"MyGramAPI/app/entity"
-	"MyGramAPI/pkg/database"
-	"MyGramAPI/pkg/helpers"
-	"net/http"
-
-	"github.com/gin-gonic/gin"
-)
-
-var (
-	appJSON = "application/json"
-)
-
-// UserRegister godoc
-// @Summary User Register
-// @Description Register an account
-// @Tags users
-// @Consumes ({mpfd,json})
-// @Produce json
-// @Param email formData string true "User's email"
-// @Param username formData string true "User's username"
-// @Param password formData string true "User's password"
-// @Param age formData int true "User's age"
-// @Success 201 {object} entity.Response "If all field filled and correct, account will created "
-// @Failure 400  {object}  entity.Response "If there is an error, data will set to nil"
-// @Router /users/register [post]
-func UserRegister(c *gin.Context) {
-	db, err := database.Connect()
-	if err != nil {
-		panic(err)
-	}
-
-	contentType := helpers.GetContentType(c)
-	_, _ = db, contentType
-	User := entity.User{}
-
-	if contentType == appJSON {
-		c.ShouldBindJSON(&User)
-	} else {
-		c.ShouldBind(&User)
-	}
-
-	err = db.Debug().Create(&User).Error
-
-	if err != nil {
-		c.JSON(http.StatusBadRequest, entity.Response{
-			Success: false,
-			Message: err.Error(),
-			Data:    nil,
-		})
-		return
-	}
-
-	c.JSON(http.StatusCreated, entity.Response{
-		Success: true,
-		Message: "Account has been created successfully",
-		Data: entity.DataRegister{
-			ID:    User.ID,
-			Email: User.Email,
-			Uname: User.Username,
-			Age:   int(User.Age),
-		},
-	})
-
-}
-
-// UserLogin godoc
-// @Summary User Login
-// @Description Login to system
-// @Tags users
-// @Consumes ({mpfd,json})
-// @Produce json
-// @Param email formData string true "User's email"
-// @Param password formData string true "User's password"
-// @Success 200 {object} entity.Response "If email and password are correct, you will get a token"
-// @Failure 401  {object}  entity.Response "If email and password are not correct, data will set to nil"
-// @Router /users/login [post]
-func UserLogin(c *gin.Context) {
-	db, _ := database.Connect()
-	contentType := helpers.GetContentType(c)
-	_, _ = db, contentType
-
-	User := entity.User{}
-	password := ""
-
-	if contentType == appJSON {
-		c.ShouldBindJSON(&User)
-	} else {
-		c.ShouldBind(&User)
-	}
-
-	password = User.Password
-	//select data user berdasarkan email
-	err := db.Debug().Where("email = ?", User.Email).Take(&User).Error
-
-	if err != nil {
-
-		c.JSON(http.StatusUnauthorized,
-			entity.Response{
-				Success: false,
-				Message: "Invalid email or password",
-				Data:    nil,
-			})
-		return
-	}
-
-	comparePass := helpers.ComparePass([]byte(User.Password), []byte(password))
-
-	if !comparePass {
-		c.JSON(http.StatusUnauthorized, entity.Response{
-			Success: false,
-			Message: "Invalid email or password",
-			Data:    nil,
-		})
-		return
-	}
-
-	token := helpers.GenerateToken(User.ID, User.Email, User.CreatedAt)
-
-	c.JSON(http.StatusOK, entity.Response{
-		Success: true,
-		Message: "User logged in successfully",
-		Data: entity.DataLogin{
-			Token: token,
-		},
-	})
-}
+package services
+
+import (
+	"MyGramAPI/app/apperror"
+	"MyGramAPI/app/entity"
+	"MyGramAPI/app/repository"
+	"MyGramAPI/pkg/helpers"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	appJSON = "application/json"
+)
+
+// UserRegister godoc
+// @Summary User Register
+// @Description Register an account
+// @Tags users
+// @Consumes ({mpfd,json})
+// @Produce json
+// @Param email formData string true "User's email"
+// @Param username formData string true "User's username"
+// @Param password formData string true "User's password"
+// @Param age formData int true "User's age"
+// @Success 201 {object} entity.Response "If all field filled and correct, account will created and a verification link is mailed"
+// @Failure 400  {object}  entity.Response "If some fields are invalid, every invalid field is listed in error.details"
+// @Failure 409  {object}  entity.Response "If the username or email is already registered"
+// @Router /users/register [post]
+func (s *Service) UserRegister(c *gin.Context) {
+	contentType := helpers.GetContentType(c)
+	User := entity.User{}
+
+	if contentType == appJSON {
+		c.ShouldBindJSON(&User)
+	} else {
+		c.ShouldBind(&User)
+	}
+
+	if fields := validate(&User); len(fields) > 0 {
+		c.Error(validationError(fields))
+		return
+	}
+
+	err := s.Users.Create(c.Request.Context(), &User)
+
+	if errors.Is(err, repository.ErrConflict) {
+		c.Error(apperror.Conflict("Username or email is already registered"))
+		return
+	}
+	if err != nil {
+		c.Error(repositoryError(err, "User"))
+		return
+	}
+
+	// the account exists already, the user can ask for another link when this one fails
+	if err := s.sendVerification(c.Request.Context(), User); err != nil {
+		log.Printf("error sending the verification mail of user %d: %v", User.ID, err)
+	}
+
+	c.JSON(http.StatusCreated, entity.Response{
+		Success: true,
+		Message: "Account has been created successfully, check your email to verify it",
+		Data: entity.DataRegister{
+			ID:            User.ID,
+			Email:         User.Email,
+			Uname:         User.Username,
+			Age:           int(User.Age),
+			EmailVerified: User.EmailVerified,
+		},
+	})
+
+}
+
+// UserLogin godoc
+// @Summary User Login
+// @Description Login to system
+// @Tags users
+// @Consumes ({mpfd,json})
+// @Produce json
+// @Param email formData string true "User's email"
+// @Param password formData string true "User's password"
+// @Success 200 {object} entity.Response "If email and password are correct, you will get an access token and a refresh token, or a challenge token to send with a code to /api/v1/users/login/2fa when two-factor authentication is enabled"
+// @Failure 401  {object}  entity.Response "If email and password are not correct, data will set to nil"
+// @Failure 429  {object}  entity.Response "If there were too many failed logins, wait for the Retry-After header or unlock the account with the mailed link"
+// @Router /users/login [post]
+func (s *Service) UserLogin(c *gin.Context) {
+	contentType := helpers.GetContentType(c)
+
+	User := entity.User{}
+	password := ""
+
+	if contentType == appJSON {
+		c.ShouldBindJSON(&User)
+	} else {
+		c.ShouldBind(&User)
+	}
+
+	password = User.Password
+	email, ip := User.Email, c.ClientIP()
+
+	if err := s.checkLoginAttempts(c.Request.Context(), email, ip); err != nil {
+		c.Error(err)
+		return
+	}
+
+	//select data user berdasarkan email
+	User, err := s.Users.FindByEmail(c.Request.Context(), email)
+
+	if errors.Is(err, repository.ErrNotFound) {
+		s.loginFailed(c.Request.Context(), email, ip, nil)
+		c.Error(apperror.Unauthorized("Invalid email or password"))
+		return
+	}
+	if err != nil {
+		c.Error(repositoryError(err, "User"))
+		return
+	}
+
+	comparePass, rehash := helpers.ComparePass(User.Password, password)
+
+	if !comparePass {
+		s.loginFailed(c.Request.Context(), email, ip, &User)
+		c.Error(apperror.Unauthorized("Invalid email or password"))
+		return
+	}
+
+	// the password is only known now, so an old hash is upgraded on the first login after the settings changed
+	if rehash {
+		s.rehashPassword(c.Request.Context(), User.ID, password)
+	}
+
+	if User.TOTPEnabled {
+		DataChallenge, err := s.loginChallenge(c.Request.Context(), User)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, entity.Response{
+			Success: true,
+			Message: "Enter the code of your authenticator app to finish logging in",
+			Data:    DataChallenge,
+		})
+		return
+	}
+
+	s.loginSucceeded(c.Request.Context(), email)
+
+	DataLogin, err := s.startSession(c.Request.Context(), User, c.Request.UserAgent(), ip)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entity.Response{
+		Success: true,
+		Message: "User logged in successfully",
+		Data:    DataLogin,
+	})
+}
+
+// UserRefresh godoc
+// @Summary Refresh the tokens
+// @Description Exchange a refresh token for a new access token and a new refresh token. Every refresh token can be used once, using it again logs out every device of that login
+// @Tags users
+// @Consumes ({mpfd,json})
+// @Produce json
+// @Param refresh_token formData string true "refresh token returned by the login or the previous refresh"
+// @Success 200 {object} entity.Response "If the refresh token is valid, you will get new tokens"
+// @Failure 401  {object}  entity.Response "If the refresh token is invalid, expired, revoked or already used"
+// @Router /api/v1/users/refresh [post]
+func (s *Service) UserRefresh(c *gin.Context) {
+	contentType := helpers.GetContentType(c)
+	Refresh := entity.RefreshRequest{}
+
+	if contentType == appJSON {
+		c.ShouldBindJSON(&Refresh)
+	} else {
+		c.ShouldBind(&Refresh)
+	}
+
+	if fields := validate(&Refresh); len(fields) > 0 {
+		c.Error(validationError(fields))
+		return
+	}
+
+	DataLogin, err := s.rotateRefreshToken(c.Request.Context(), Refresh.RefreshToken, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entity.Response{
+		Success: true,
+		Message: "Tokens has been refreshed successfully",
+		Data:    DataLogin,
+	})
+}
+
+// UserLogout godoc
+// @Summary User Logout
+// @Description Revoke the token used to call this endpoint. Send the refresh token too, so it can't be exchanged for a new token anymore
+// @Tags users
+// @Consumes ({mpfd,json})
+// @Produce json
+// @Param refresh_token formData string false "refresh token returned with the access token"
+// @Success 200 {object} entity.Response "If you are logged in, the token is revoked"
+// @Failure 401  {object}  entity.Response "If you are not logged in"
+// @Security Bearer
+// @Router /api/v1/users/logout [post]
+func (s *Service) UserLogout(c *gin.Context) {
+	userData := c.MustGet("userData").(*helpers.Claims)
+	contentType := helpers.GetContentType(c)
+	Refresh := entity.RefreshRequest{}
+
+	userID := userData.UserID()
+	expiresAt := time.Unix(userData.ExpiresAt, 0)
+
+	if contentType == appJSON {
+		c.ShouldBindJSON(&Refresh)
+	} else {
+		c.ShouldBind(&Refresh)
+	}
+
+	err := s.logout(c.Request.Context(), userID, userData.SessionID, userData.Id, expiresAt, Refresh.RefreshToken)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entity.Response{
+		Success: true,
+		Message: "User logged out successfully",
+		Data:    nil,
+	})
+}
+
+// UserLogoutAll godoc
+// @Summary User Logout from all devices
+// @Description Revoke every token issued to you so far, every device has to log in again
+// @Tags users
+// @Produce json
+// @Success 200 {object} entity.Response "If you are logged in, every token is revoked"
+// @Failure 401  {object}  entity.Response "If you are not logged in"
+// @Security Bearer
+// @Router /api/v1/users/logout-all [post]
+func (s *Service) UserLogoutAll(c *gin.Context) {
+	userData := c.MustGet("userData").(*helpers.Claims)
+	userID := userData.UserID()
+
+	err := s.logoutAll(c.Request.Context(), userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entity.Response{
+		Success: true,
+		Message: "User logged out of all devices successfully",
+		Data:    nil,
+	})
+}
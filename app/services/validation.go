@@ -0,0 +1,54 @@
+package services
+
+import (
+	"MyGramAPI/app/apperror"
+	"MyGramAPI/app/entity"
+	"strings"
+
+	"github.com/asaskevich/govalidator"
+)
+
+// validate checks the valid tags of the struct and returns every failing field
+func validate(v interface{}) []entity.FieldError {
+	_, err := govalidator.ValidateStruct(v)
+	return fieldErrors(err)
+}
+
+// fieldErrors flattens the errors of govalidator into one entry per failing field
+func fieldErrors(err error) []entity.FieldError {
+	fields := []entity.FieldError{}
+
+	switch err := err.(type) {
+	case nil:
+	case govalidator.Errors:
+		for _, fieldErr := range err {
+			fields = append(fields, fieldErrors(fieldErr)...)
+		}
+	case govalidator.Error:
+		path := append(append([]string{}, err.Path...), err.Name)
+		fields = append(fields, entity.FieldError{
+			Field:   strings.Join(path, "."),
+			Code:    err.Validator,
+			Message: err.Err.Error(),
+		})
+	default:
+		fields = append(fields, entity.FieldError{Code: "invalid", Message: err.Error()})
+	}
+	return fields
+}
+
+// validationError returns the error sent when some fields are invalid, the fields are listed in the details
+func validationError(fields []entity.FieldError) error {
+	return apperror.Validation("Some fields are invalid", fields)
+}
+
+// without removes the errors of a field, used when the handler checks that field itself
+func without(fields []entity.FieldError, field string) []entity.FieldError {
+	kept := []entity.FieldError{}
+	for _, fieldErr := range fields {
+		if fieldErr.Field != field {
+			kept = append(kept, fieldErr)
+		}
+	}
+	return kept
+}
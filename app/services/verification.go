@@ -0,0 +1,102 @@
+package services
+
+import (
+	"MyGramAPI/app/apperror"
+	"MyGramAPI/app/entity"
+	"MyGramAPI/pkg/helpers"
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UserVerifyEmail godoc
+// @Summary Verify the email
+// @Description Confirm the email with the token of the link mailed on register. A token works once
+// @Tags users
+// @Consumes ({mpfd,json})
+// @Produce json
+// @Param token formData string true "token of the verification link"
+// @Success 200 {object} entity.Response "If the token is valid, the email is verified"
+// @Failure 400  {object}  entity.Response "If the token is invalid, expired or already used"
+// @Router /api/v1/users/verify-email [post]
+func (s *Service) UserVerifyEmail(c *gin.Context) {
+	ctx := c.Request.Context()
+	contentType := helpers.GetContentType(c)
+	Verification := entity.EmailVerification{}
+
+	if contentType == appJSON {
+		c.ShouldBindJSON(&Verification)
+	} else {
+		c.ShouldBind(&Verification)
+	}
+
+	if fields := validate(&Verification); len(fields) > 0 {
+		c.Error(validationError(fields))
+		return
+	}
+
+	UserToken, err := s.useUserToken(ctx, entity.PurposeEmailVerification, Verification.Token, apperror.Validation("Verification token is invalid or has expired", nil))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := s.Users.MarkEmailVerified(ctx, UserToken.UserID); err != nil {
+		c.Error(repositoryError(err, "User"))
+		return
+	}
+
+	c.JSON(http.StatusOK, entity.Response{
+		Success: true,
+		Message: "Email has been verified successfully",
+		Data:    nil,
+	})
+}
+
+// UserResendVerification godoc
+// @Summary Resend the verification link
+// @Description Mail a new verification link to your email, the previous links stop working
+// @Tags users
+// @Produce json
+// @Success 200 {object} entity.Response "If your email isn't verified yet, a new link is mailed"
+// @Failure 401  {object}  entity.Response "If you are not logged in"
+// @Failure 409  {object}  entity.Response "If your email is already verified"
+// @Failure 503  {object}  entity.Response "If the mail can't be sent, error will appear"
+// @Security Bearer
+// @Router /api/v1/users/verify-email/resend [post]
+func (s *Service) UserResendVerification(c *gin.Context) {
+	userData := c.MustGet("userData").(*helpers.Claims)
+	userID := userData.UserID()
+
+	User, err := s.Users.FindByID(c.Request.Context(), userID)
+	if err != nil {
+		c.Error(repositoryError(err, "User"))
+		return
+	}
+
+	if User.EmailVerified {
+		c.Error(apperror.Conflict("Email is already verified"))
+		return
+	}
+
+	if err := s.sendVerification(c.Request.Context(), User); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entity.Response{
+		Success: true,
+		Message: "Verification link has been sent to your email",
+		Data:    nil,
+	})
+}
+
+// sendVerification mails a new verification link to the user
+func (s *Service) sendVerification(ctx context.Context, user entity.User) error {
+	return s.mailToken(ctx, user, entity.PurposeEmailVerification, s.VerificationTTL, s.VerificationURL,
+		"Verify your MyGram email",
+		"Hi %s,\n\n"+
+			"Welcome to MyGram! Use this within %s to verify your email:\n\n%s\n\n"+
+			"If you didn't create an account, ignore this mail.\n")
+}
@@ -52,7 +52,7 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "If email and password are correct, you will get a token",
+                        "description": "If email and password are correct, you will get an access token and a refresh token, or a challenge token to send with a code to /api/v1/users/login/2fa when two-factor authentication is enabled",
                         "schema": {
                             "$ref": "#/definitions/entity.Response"
                         }
@@ -62,6 +62,12 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/entity.Response"
                         }
+                    },
+                    "429": {
+                        "description": "If there were too many failed logins, wait for the Retry-After header or unlock the account with the mailed link",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
                     }
                 }
             }
@@ -108,13 +114,19 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "201": {
-                        "description": "If all field filled and correct, account will created ",
+                        "description": "If all field filled and correct, account will created and a verification link is mailed",
                         "schema": {
                             "$ref": "#/definitions/entity.Response"
                         }
                     },
                     "400": {
-                        "description": "If there is an error, data will set to nil",
+                        "description": "If some fields are invalid, every invalid field is listed in error.details",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "If the username or email is already registered",
                         "schema": {
                             "$ref": "#/definitions/entity.Response"
                         }
@@ -132,6 +144,20 @@ const docTemplate = `{
                     "comments"
                 ],
                 "summary": "Get all comments",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "page number, starts at 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "items per page, 20 by default and 100 at most",
+                        "name": "per_page",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "Will send all comments",
@@ -139,8 +165,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/entity.Response"
                         }
                     },
-                    "404": {
-                        "description": "If there is no comment, error will appear",
+                    "503": {
+                        "description": "If the database is unavailable, error will appear",
                         "schema": {
                             "$ref": "#/definitions/entity.Response"
                         }
@@ -184,6 +210,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/entity.Response"
                         }
                     },
+                    "400": {
+                        "description": "If some parameters are invalid, every invalid field is listed in error.details",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
                     "401": {
                         "description": "If you are not login or some parameters not filled, error will appear",
                         "schema": {
@@ -270,6 +302,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/entity.Response"
                         }
                     },
+                    "403": {
+                        "description": "If it's not your own comment",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
                     "404": {
                         "description": "If there is something wrong, error will appear",
                         "schema": {
@@ -284,7 +322,7 @@ const docTemplate = `{
                         "Bearer": []
                     }
                 ],
-                "description": "User can delete their own comment.",
+                "description": "User can delete their own comment and the comments on their photos, moderators and admins can delete any comment.",
                 "produces": [
                     "application/json"
                 ],
@@ -303,7 +341,7 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "If comment is exist and it's your own comment",
+                        "description": "If comment is exist and it's your own comment or you are a moderator",
                         "schema": {
                             "$ref": "#/definitions/entity.Response"
                         }
@@ -313,6 +351,18 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/entity.Response"
                         }
+                    },
+                    "403": {
+                        "description": "If it's not your own comment and you can't moderate it",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "If the comment doesn't exist",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
                     }
                 }
             }
@@ -327,6 +377,20 @@ const docTemplate = `{
                     "photos"
                 ],
                 "summary": "Get all photos",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "page number, starts at 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "items per page, 20 by default and 100 at most",
+                        "name": "per_page",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "Will send all photos",
@@ -334,8 +398,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/entity.Response"
                         }
                     },
-                    "404": {
-                        "description": "If there is no photos, error will appear",
+                    "503": {
+                        "description": "If the database is unavailable, error will appear",
                         "schema": {
                             "$ref": "#/definitions/entity.Response"
                         }
@@ -393,11 +457,23 @@ const docTemplate = `{
                             "$ref": "#/definitions/entity.Response"
                         }
                     },
+                    "400": {
+                        "description": "If some parameters are invalid, every invalid field is listed in error.details",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
                     "404": {
                         "description": "If you are not login or some parameters not filled, error will appear",
                         "schema": {
                             "$ref": "#/definitions/entity.Response"
                         }
+                    },
+                    "503": {
+                        "description": "If the media storage is unavailable, error will appear",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
                     }
                 }
             }
@@ -473,11 +549,10 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "photo url",
+                        "type": "file",
+                        "description": "the new photo, the current one is kept without it",
                         "name": "photo_url",
-                        "in": "formData",
-                        "required": true
+                        "in": "formData"
                     }
                 ],
                 "responses": {
@@ -487,11 +562,29 @@ const docTemplate = `{
                             "$ref": "#/definitions/entity.Response"
                         }
                     },
+                    "400": {
+                        "description": "If some parameters are invalid, every invalid field is listed in error.details",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
                     "401": {
                         "description": "If there is something wrong, error will appear",
                         "schema": {
                             "$ref": "#/definitions/entity.Response"
                         }
+                    },
+                    "403": {
+                        "description": "If it's not your own photo",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "If the photo doesn't exist",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
                     }
                 }
             },
@@ -501,7 +594,7 @@ const docTemplate = `{
                         "Bearer": []
                     }
                 ],
-                "description": "User can delete their own photo.",
+                "description": "User can delete their own photo, moderators and admins can delete any photo.",
                 "produces": [
                     "application/json"
                 ],
@@ -520,7 +613,7 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "If photo is exist and it's your own photo, photo will deleted",
+                        "description": "If photo is exist and it's your own photo or you are a moderator, photo will deleted",
                         "schema": {
                             "$ref": "#/definitions/entity.Response"
                         }
@@ -530,6 +623,18 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/entity.Response"
                         }
+                    },
+                    "403": {
+                        "description": "If it's not your own photo and you can't moderate it",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "If the photo doesn't exist",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
                     }
                 }
             }
@@ -544,6 +649,20 @@ const docTemplate = `{
                     "social-medias"
                 ],
                 "summary": "Get all social media",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "page number, starts at 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "items per page, 20 by default and 100 at most",
+                        "name": "per_page",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "Will send all social media datas",
@@ -551,8 +670,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/entity.Response"
                         }
                     },
-                    "404": {
-                        "description": "If there is no social media, error will appear",
+                    "503": {
+                        "description": "If the database is unavailable, error will appear",
                         "schema": {
                             "$ref": "#/definitions/entity.Response"
                         }
@@ -596,6 +715,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/entity.Response"
                         }
                     },
+                    "400": {
+                        "description": "If some parameters are invalid, every invalid field is listed in error.details",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
                     "401": {
                         "description": "If you are not login or some parameters not filled, error will appear",
                         "schema": {
@@ -688,6 +813,18 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/entity.Response"
                         }
+                    },
+                    "403": {
+                        "description": "If it's not your own social media",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "If the social media doesn't exist",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
                     }
                 }
             },
@@ -726,23 +863,1147 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/entity.Response"
                         }
+                    },
+                    "403": {
+                        "description": "If it's not your own social media",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "If the social media doesn't exist",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
                     }
                 }
             }
-        }        
-    },
-    "definitions": {
-        "entity.Response": {
-            "type": "object",
-            "properties": {
-                "data": {},
-                "message": {
-                    "type": "string",
-                    "example": "created"
-                },
-                "success": {
-                    "type": "boolean",
-                    "example": true
+        }        ,
+        "/api/v1/users/refresh": {
+            "post": {
+                "description": "Exchange a refresh token for a new access token and a new refresh token. Every refresh token can be used once, using it again logs out every device of that login",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Refresh the tokens",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "refresh token returned by the login or the previous refresh",
+                        "name": "refresh_token",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "If the refresh token is valid, you will get new tokens",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "If the refresh token is invalid, expired, revoked or already used",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/logout": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Revoke the token used to call this endpoint. Send the refresh token too, so it can't be exchanged for a new token anymore",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "User Logout",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "refresh token returned with the access token",
+                        "name": "refresh_token",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "If you are logged in, the token is revoked",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "If you are not logged in",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/logout-all": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Revoke every token issued to you so far, every device has to log in again",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "User Logout from all devices",
+                "responses": {
+                    "200": {
+                        "description": "If you are logged in, every token is revoked",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "If you are not logged in",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/.well-known/jwks.json": {
+            "get": {
+                "description": "Public keys of the RS256 and EdDSA signing keys as a JSON Web Key Set, other services use them to verify the tokens. The kid header of a token selects its key",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get the token verification keys",
+                "responses": {
+                    "200": {
+                        "description": "The public keys",
+                        "schema": {
+                            "$ref": "#/definitions/helpers.JSONWebKeySet"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/password-reset": {
+            "post": {
+                "description": "Send a password reset link to the email. The answer is the same whether the email is registered or not, so it can't be used to find accounts. The requests are limited per email and per IP address",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Request a password reset",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User's email",
+                        "name": "email",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "If the email is valid, a reset link is sent when it's registered",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "If the email is invalid, every invalid field is listed in error.details",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "429": {
+                        "description": "If there were too many reset requests for the email or from your network, wait for the Retry-After header",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/password-reset/confirm": {
+            "post": {
+                "description": "Set a new password with the token of the reset link. A token works once, and every device is logged out after the reset",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Reset the password",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "token of the reset link",
+                        "name": "token",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "new password",
+                        "name": "password",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "If the token is valid, the password is changed",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "If the token is invalid, expired or already used, or the password is invalid",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/verify-email": {
+            "post": {
+                "description": "Confirm the email with the token of the link mailed on register. A token works once",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Verify the email",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "token of the verification link",
+                        "name": "token",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "If the token is valid, the email is verified",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "If the token is invalid, expired or already used",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/verify-email/resend": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Mail a new verification link to your email, the previous links stop working",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Resend the verification link",
+                "responses": {
+                    "200": {
+                        "description": "If your email isn't verified yet, a new link is mailed",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "If you are not logged in",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "If your email is already verified",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "503": {
+                        "description": "If the mail can't be sent, error will appear",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/login/2fa": {
+            "post": {
+                "description": "Finish the login of an account with two-factor authentication. Send the challenge token returned by the login with a code of the authenticator app or a recovery code. Each code works once",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "User Login, second step",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "challenge token returned by the login",
+                        "name": "challenge_token",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "code of the authenticator app or a recovery code",
+                        "name": "code",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "If the code is valid, you will get an access token and a refresh token",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "If some fields are missing, every invalid field is listed in error.details",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "If the challenge has expired or the code is invalid",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "429": {
+                        "description": "If there were too many failed logins, wait for the Retry-After header or unlock the account with the mailed link",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/2fa/enroll": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Generate a TOTP secret for your authenticator app. Add it with the otpauth URI or by scanning the QR code, then confirm it with a code. Enrolling again replaces a secret that isn't confirmed yet",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Enroll in two-factor authentication",
+                "responses": {
+                    "200": {
+                        "description": "The secret, its otpauth URI and its QR code",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "If you are not logged in",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "If two-factor authentication is already enabled",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/2fa/confirm": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Enable two-factor authentication with a code of the authenticator app. The recovery codes are only shown in this response, each one can replace a code once",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Confirm two-factor authentication",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "code of the authenticator app",
+                        "name": "code",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "If the code is valid, two-factor authentication is enabled",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "If the code is invalid or you haven't enrolled",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "If you are not logged in",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "If two-factor authentication is already enabled",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/2fa/recovery-codes": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Replace your recovery codes, the previous ones stop working. The new codes are only shown in this response",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Generate new recovery codes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "code of the authenticator app",
+                        "name": "code",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "If the code is valid, the new recovery codes",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "If the code is invalid",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "If you are not logged in",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "If two-factor authentication isn't enabled",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/2fa/disable": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Remove the TOTP secret and the recovery codes, the login only asks for the password again",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Disable two-factor authentication",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "code of the authenticator app or a recovery code",
+                        "name": "code",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "If the code is valid, two-factor authentication is disabled",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "If the code is invalid",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "If you are not logged in",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "If two-factor authentication isn't enabled",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/unlock": {
+            "post": {
+                "description": "Unlock an account locked out after too many failed logins, with the token of the link mailed on the lockout. A token works once",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Unlock the account",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "token of the unlock link",
+                        "name": "token",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "If the token is valid, the account can log in again",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "If the token is invalid, expired or already used",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/oidc/login": {
+            "get": {
+                "description": "Redirect to the login page of the OpenID Connect provider, which sends the user back to /api/v1/users/oidc/callback. Open it in the browser, the login is tied to the browser by a cookie",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Log in with the OpenID Connect provider",
+                "responses": {
+                    "302": {
+                        "description": "Redirect to the login page of the provider",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "If the OpenID Connect login isn't configured",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "503": {
+                        "description": "If the provider is unavailable, error will appear",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/oidc/callback": {
+            "get": {
+                "description": "The provider redirects here after the login. The first login links the provider account to the MyGram account with the same verified email or creates a new account, every login then returns the tokens of a normal login",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Finish the login with the OpenID Connect provider",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization code returned by the provider",
+                        "name": "code",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "state returned by the provider",
+                        "name": "state",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "If the login is verified, you will get an access token and a refresh token, or a challenge token when two-factor authentication is enabled",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "If the login has expired or was started in another browser",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "If the provider refused the login or it can't be verified",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "If the provider didn't share the email",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "If the OpenID Connect login isn't configured",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "If an account already uses the email but one of the emails isn't verified",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "503": {
+                        "description": "If the provider is unavailable, error will appear",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/me/tokens": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "List the personal access tokens of the user, newest first, with their scopes, expiry and last use. The tokens themselves are never shown again",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "List the personal access tokens",
+                "responses": {
+                    "200": {
+                        "description": "The tokens of the user",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "If you are not logged in",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "If the request is made with a personal access token",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Create a token for scripts, sent as \"Bearer mgp_...\" like the login token. It can only be used on the routes of its scopes: photos:write (upload, edit and delete photos), comments:write (post, edit and delete comments) or read-only (list your tokens and sessions). The token is only shown in this response, it never expires without expires_at",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Create a personal access token",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "name of the token, to recognize it later",
+                        "name": "name",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "scopes of the token: photos:write, comments:write or read-only",
+                        "name": "scopes",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "when the token expires, RFC 3339, example: 2030-01-01T00:00:00Z",
+                        "name": "expires_at",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "The token, save it now since it's not shown again",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "If some fields are invalid, every invalid field is listed in error.details",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "If you are not logged in",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "If the request is made with a personal access token",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/me/tokens/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Delete a personal access token of the user, it's rejected right away",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Revoke a personal access token",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "access token id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "If the token is revoked",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "If you are not logged in",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "If the request is made with a personal access token",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "If you have no token with the id",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/users": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Admins can list every user with their role, oldest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List the users",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "page number, starts at 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "items per page, 20 by default and 100 at most",
+                        "name": "per_page",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The users",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "If you are not logged in",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "If you are not an admin or the request is made with a personal access token",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/users/{id}/role": {
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Admins can make a user a moderator, an admin or a normal user again. Admins can't change their own role, so there is always an admin left",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Change the role of a user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "the new role: user, moderator or admin",
+                        "name": "role",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The user with the new role",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "If the role is invalid or it's your own account",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "If you are not logged in",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "If you are not an admin or the request is made with a personal access token",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "If the user doesn't exist",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/me/sessions": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "List the devices the user is logged in on, last seen first, with their user agent and the IP they were last seen from. current is true for the session of the request",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "List the sessions",
+                "responses": {
+                    "200": {
+                        "description": "The sessions of the user",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "If you are not logged in",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "If the request is made with a personal access token",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/me/sessions/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Log the device of the session out, its access token and refresh token are rejected right away. Removing the current session logs you out",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Log out a session",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "session id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "If the session is removed",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "If you are not logged in",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "If the request is made with a personal access token",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "If you have no session with the id",
+                        "schema": {
+                            "$ref": "#/definitions/entity.Response"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "entity.Error": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "not_found"
+                },
+                "details": {}
+            }
+        },
+        "entity.Pagination": {
+            "type": "object",
+            "properties": {
+                "page": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "per_page": {
+                    "type": "integer",
+                    "example": 20
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "total_pages": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "entity.Response": {
+            "type": "object",
+            "properties": {
+                "data": {},
+                "error": {
+                    "$ref": "#/definitions/entity.Error"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "created"
+                },
+                "meta": {
+                    "$ref": "#/definitions/entity.Pagination"
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "helpers.JSONWebKey": {
+            "type": "object",
+            "properties": {
+                "alg": {
+                    "type": "string"
+                },
+                "crv": {
+                    "description": "Crv and X are set for Ed25519 keys",
+                    "type": "string"
+                },
+                "e": {
+                    "type": "string"
+                },
+                "kid": {
+                    "type": "string"
+                },
+                "kty": {
+                    "type": "string"
+                },
+                "n": {
+                    "description": "N and E are set for RSA keys",
+                    "type": "string"
+                },
+                "use": {
+                    "type": "string"
+                },
+                "x": {
+                    "type": "string"
+                }
+            }
+        },
+        "helpers.JSONWebKeySet": {
+            "type": "object",
+            "properties": {
+                "keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/helpers.JSONWebKey"
+                    }
                 }
             }
         }
@@ -1,43 +1,134 @@
-package main
-
-import (
-	"MyGramAPI/app/routers"
-	"MyGramAPI/pkg/helpers"
-	"log"
-
-	"github.com/joho/godotenv"
-)
-
-// @title MyGram API
-// @version 1.0
-// @description This is an API for MyGram APP. To use all of the services, please login first and get the token.
-// @description Once you've completed the previous steps, you'll need to locate the "Authorize" button on the right-hand side of the screen and click it. This will trigger a pop-up window to appear, in which you should enter your token preceded by the word "Bearer". For instance, your token might look something like "eyJhbGciOiJIUzI1...", so you would enter "Bearer eyJhbGciOiJIUzI1..." into the designated field.
-
-// @contact.name   API Support
-// @contact.url    http://www.swagger.io/support
-// @contact.email  danisetiawan609@gmail.com
-
-// @license.name  Apache 2.0
-// @license.url   http://www.apache.org/licenses/LICENSE-2.0.html
-
-// @securityDefinitions.apikey Bearer
-// @in header
-// @name Authorization
-// @description Type "Bearer" followed by a space and JWT token.
-
-// @host localhost:8082
-// @BasePath /
-// @swagg.NoModels
-
-func init() {
-		err := godotenv.Load()
-		if err != nil {
-			log.Fatal("Error loading .env file")
-		}
-}
-
-func main() {
-
-	helpers.InitCloudinary()
-	routers.StartServer().Run()
-}
+package main
+
+import (
+	"MyGramAPI/app/routers"
+	"MyGramAPI/pkg/attempts"
+	"MyGramAPI/pkg/config"
+	"MyGramAPI/pkg/database"
+	"MyGramAPI/pkg/helpers"
+	"MyGramAPI/pkg/mail"
+	"MyGramAPI/pkg/revocation"
+	"MyGramAPI/pkg/storage"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"gorm.io/gorm"
+)
+
+// @title MyGram API
+// @version 1.0
+// @description This is an API for MyGram APP. To use all of the services, please login first and get the token.
+// @description Once you've completed the previous steps, you'll need to locate the "Authorize" button on the right-hand side of the screen and click it. This will trigger a pop-up window to appear, in which you should enter your token preceded by the word "Bearer". For instance, your token might look something like "eyJhbGciOiJIUzI1...", so you would enter "Bearer eyJhbGciOiJIUzI1..." into the designated field.
+
+// @contact.name   API Support
+// @contact.url    http://www.swagger.io/support
+// @contact.email  danisetiawan609@gmail.com
+
+// @license.name  Apache 2.0
+// @license.url   http://www.apache.org/licenses/LICENSE-2.0.html
+
+// @securityDefinitions.apikey Bearer
+// @in header
+// @name Authorization
+// @description Type "Bearer" followed by a space and JWT token.
+
+// @host localhost:8082
+// @BasePath /
+// @swagg.NoModels
+
+func main() {
+	configFile := flag.String("config", "", "path to an optional YAML or TOML config file, CONFIG_FILE by default")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: mygram [-config file] [serve | migrate up|down|status | role email user|moderator|admin]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if err := run(*configFile, flag.Args()); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(configFile string, args []string) error {
+	command := "serve"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	// the other commands only use the database, so they don't need the settings of the server
+	load := config.LoadDatabase
+	if command == "serve" {
+		load = config.Load
+	}
+	cfg, err := load(configFile)
+	if err != nil {
+		return err
+	}
+
+	db, err := database.Connect(cfg.Database)
+	if err != nil {
+		return fmt.Errorf("error connecting to database: %w", err)
+	}
+	defer func() {
+		if err := database.Close(db); err != nil {
+			log.Println("error closing database:", err.Error())
+		}
+	}()
+
+	switch command {
+	case "serve":
+		return serve(cfg, db)
+	case "migrate":
+		return migrate(db, args[1:])
+	case "role":
+		return role(db, args[1:])
+	default:
+		flag.Usage()
+		return fmt.Errorf("unknown command %q", command)
+	}
+}
+
+func serve(cfg config.Config, db *gorm.DB) error {
+	if cfg.Database.AutoMigrate {
+		if err := migrate(db, []string{"up"}); err != nil {
+			return err
+		}
+	}
+
+	media, err := storage.New(cfg.Storage)
+	if err != nil {
+		return fmt.Errorf("error initializing media storage: %w", err)
+	}
+
+	revocations, err := revocation.New(cfg.JWT.RevocationStore, db)
+	if err != nil {
+		return fmt.Errorf("error initializing token revocation store: %w", err)
+	}
+
+	loginAttempts, err := attempts.New(cfg.Auth.LoginAttemptStore, db)
+	if err != nil {
+		return fmt.Errorf("error initializing login attempt store: %w", err)
+	}
+
+	mailer, err := mail.New(cfg.Mail)
+	if err != nil {
+		return fmt.Errorf("error initializing mailer: %w", err)
+	}
+
+	if err := helpers.InitJWT(cfg.JWT); err != nil {
+		return err
+	}
+	if err := helpers.InitPasswords(cfg.Password); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return routers.Serve(ctx, cfg.Server, routers.NewRouter(db, media, revocations, loginAttempts, mailer, cfg))
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"MyGramAPI/pkg/database"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// migrate runs the "migrate up|down|status" command
+func migrate(db *gorm.DB, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: mygram migrate up|down|status")
+	}
+
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, migration := range applied {
+			log.Printf("applied migration %d_%s", migration.Version, migration.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			log.Println("database is up to date")
+		}
+		return err
+	case "down":
+		migration, err := migrator.Down(ctx)
+		if err != nil {
+			return err
+		}
+		if migration == nil {
+			log.Println("there is no migration to roll back")
+			return nil
+		}
+		log.Printf("rolled back migration %d_%s", migration.Version, migration.Name)
+		return nil
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown migrate command %q, use up, down or status", args[0])
+	}
+}
@@ -0,0 +1,80 @@
+package attempts
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Counter is the number of failed attempts of a key in a row
+type Counter struct {
+	Failures    int
+	LastFailure time.Time
+}
+
+// Store counts the failed login attempts by key, example: account:user@mail.com or ip:10.0.0.1
+type Store interface {
+	// Fail records a failed attempt at the given time and returns the new counter, failures before since are forgotten first
+	Fail(ctx context.Context, key string, at, since time.Time) (Counter, error)
+	// Get returns the counter of the key, a key without failures has a zero Counter
+	Get(ctx context.Context, key string) (Counter, error)
+	// Reset forgets the failures of the key, after a successful login or an unlock
+	Reset(ctx context.Context, key string) error
+}
+
+// New returns the Store selected by the driver, database keeps the counters in the given database
+func New(driver string, db *gorm.DB) (Store, error) {
+	switch driver {
+	case "database":
+		return NewDatabase(db), nil
+	case "memory":
+		return NewMemory(), nil
+	default:
+		return nil, fmt.Errorf("unknown login attempt store %q", driver)
+	}
+}
+
+// Policy decides how long a key waits after its failures.
+// The first FreeAttempts failures don't wait, then the wait doubles from BaseDelay up to MaxDelay,
+// and LockoutAttempts failures lock the key out for LockoutDuration.
+type Policy struct {
+	FreeAttempts    int
+	BaseDelay       time.Duration
+	MaxDelay        time.Duration
+	LockoutAttempts int
+	LockoutDuration time.Duration
+}
+
+// Wait returns how long the key has to wait before its next attempt and whether it's locked out, zero means it can try now
+func (p Policy) Wait(counter Counter, now time.Time) (time.Duration, bool) {
+	// the failures are forgotten once the lockout would be over, like Fail does with since
+	if counter.Failures == 0 || now.Sub(counter.LastFailure) >= p.LockoutDuration {
+		return 0, false
+	}
+
+	if counter.Failures >= p.LockoutAttempts {
+		return counter.LastFailure.Add(p.LockoutDuration).Sub(now), true
+	}
+
+	if counter.Failures < p.FreeAttempts {
+		return 0, false
+	}
+
+	delay := p.MaxDelay
+	if shift := counter.Failures - p.FreeAttempts; shift < 30 && p.BaseDelay<<shift < p.MaxDelay {
+		delay = p.BaseDelay << shift
+	}
+
+	wait := counter.LastFailure.Add(delay).Sub(now)
+	if wait < 0 {
+		return 0, false
+	}
+	return wait, false
+}
+
+// Since returns the time before which the failures are forgotten, it's passed to Fail
+func (p Policy) Since(now time.Time) time.Time {
+	return now.Add(-p.LockoutDuration)
+}
@@ -0,0 +1,156 @@
+package attempts_test
+
+import (
+	"MyGramAPI/pkg/attempts"
+	"MyGramAPI/pkg/config"
+	"MyGramAPI/pkg/database"
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// stores returns a constructor of an empty store for every implementation, the database one runs on a migrated
+// SQLite database, so both are held to the same contract
+var stores = map[string]func(t *testing.T) attempts.Store{
+	"database": func(t *testing.T) attempts.Store {
+		db, err := database.Connect(config.Database{DSN: "sqlite://" + filepath.Join(t.TempDir(), "test.db")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { database.Close(db) })
+
+		migrator, err := database.NewMigrator(db)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := migrator.Up(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		return attempts.NewDatabase(db)
+	},
+	"memory": func(t *testing.T) attempts.Store {
+		return attempts.NewMemory()
+	},
+}
+
+func TestPolicyWait(t *testing.T) {
+	policy := attempts.Policy{
+		FreeAttempts:    3,
+		BaseDelay:       time.Second,
+		MaxDelay:        10 * time.Second,
+		LockoutAttempts: 10,
+		LockoutDuration: time.Hour,
+	}
+	last := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		failures int
+		after    time.Duration
+		wait     time.Duration
+		locked   bool
+	}{
+		{"no failures", 0, 0, 0, false},
+		{"free failures", 2, 0, 0, false},
+		// the wait doubles with every failure after the free ones
+		{"first delayed failure", 3, 0, time.Second, false},
+		{"second delayed failure", 4, 0, 2 * time.Second, false},
+		{"third delayed failure", 5, 0, 4 * time.Second, false},
+		{"part of the wait is over", 5, 3 * time.Second, time.Second, false},
+		{"the wait is over", 5, 4 * time.Second, 0, false},
+		{"the wait stops at the max delay", 9, 0, 10 * time.Second, false},
+		// the lockout lasts from the last failure, then the failures are forgotten
+		{"lockout", 10, 0, time.Hour, true},
+		{"part of the lockout is over", 10, 59 * time.Minute, time.Minute, true},
+		{"failures after the lockout", 25, time.Minute, 59 * time.Minute, true},
+		{"the lockout is over", 10, time.Hour, 0, false},
+	}
+
+	for _, test := range tests {
+		wait, locked := policy.Wait(attempts.Counter{Failures: test.failures, LastFailure: last}, last.Add(test.after))
+		if wait != test.wait || locked != test.locked {
+			t.Errorf("%s: got a wait of %s and locked %t, want %s and %t", test.name, wait, locked, test.wait, test.locked)
+		}
+	}
+
+	// a long row of failures below the lockout doesn't overflow the doubling
+	policy.LockoutAttempts = 1000
+	if wait, locked := policy.Wait(attempts.Counter{Failures: 100, LastFailure: last}, last); wait != policy.MaxDelay || locked {
+		t.Errorf("100 failures: got a wait of %s and locked %t, want the max delay", wait, locked)
+	}
+
+	if since := policy.Since(last); !since.Equal(last.Add(-time.Hour)) {
+		t.Errorf("got failures forgotten before %s, want an hour earlier", since)
+	}
+}
+
+func TestStore(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	since := func(at time.Time) time.Time { return at.Add(-time.Hour) }
+
+	for name, open := range stores {
+		open := open
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			store := open(t)
+
+			if counter, err := store.Get(ctx, "account:alice@example.com"); err != nil || counter.Failures != 0 {
+				t.Fatalf("unknown key: got %+v and %v, want no failures", counter, err)
+			}
+
+			// every failure is counted and moves the last failure
+			for i := 1; i <= 3; i++ {
+				at := start.Add(time.Duration(i) * time.Minute)
+				counter, err := store.Fail(ctx, "account:alice@example.com", at, since(at))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if counter.Failures != i || !counter.LastFailure.Equal(at) {
+					t.Errorf("failure %d: got %+v, want %d failures, the last at %s", i, counter, i, at)
+				}
+			}
+			checkCounter(t, store, "account:alice@example.com", 3)
+
+			// the keys are counted apart
+			if _, err := store.Fail(ctx, "ip:203.0.113.7", start, since(start)); err != nil {
+				t.Fatal(err)
+			}
+			checkCounter(t, store, "ip:203.0.113.7", 1)
+
+			// the failures before since are forgotten, the count starts again
+			later := start.Add(2 * time.Hour)
+			counter, err := store.Fail(ctx, "account:alice@example.com", later, since(later))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if counter.Failures != 1 {
+				t.Errorf("failure after the forgotten ones: got %d failures, want 1", counter.Failures)
+			}
+			checkCounter(t, store, "ip:203.0.113.7", 0)
+
+			if err := store.Reset(ctx, "account:alice@example.com"); err != nil {
+				t.Fatal(err)
+			}
+			checkCounter(t, store, "account:alice@example.com", 0)
+
+			// resetting a key without failures is not an error
+			if err := store.Reset(ctx, "account:bob@example.com"); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+// checkCounter checks the number of failures of the key
+func checkCounter(t *testing.T, store attempts.Store, key string, failures int) {
+	t.Helper()
+
+	counter, err := store.Get(context.Background(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counter.Failures != failures {
+		t.Errorf("%s: got %d failures, want %d", key, counter.Failures, failures)
+	}
+}
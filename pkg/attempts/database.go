@@ -0,0 +1,70 @@
+package attempts
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// loginAttempt is a row of the login_attempts table
+type loginAttempt struct {
+	AttemptKey  string `gorm:"primaryKey"`
+	Failures    int
+	LastFailure time.Time
+}
+
+func (loginAttempt) TableName() string {
+	return "login_attempts"
+}
+
+// DatabaseStore keeps the counters in the database, so every instance of the API sees them
+type DatabaseStore struct {
+	db *gorm.DB
+}
+
+// NewDatabase returns a DatabaseStore using the table created by the migrations
+func NewDatabase(db *gorm.DB) *DatabaseStore {
+	return &DatabaseStore{db: db}
+}
+
+func (s *DatabaseStore) Fail(ctx context.Context, key string, at, since time.Time) (Counter, error) {
+	db := s.db.WithContext(ctx)
+
+	// forgotten counters are removed, so the table doesn't grow forever
+	if err := db.Where("last_failure < ?", since).Delete(&loginAttempt{}).Error; err != nil {
+		return Counter{}, err
+	}
+
+	// the counter is incremented by the database, so concurrent failures are all counted
+	attempt := loginAttempt{AttemptKey: key, Failures: 1, LastFailure: at}
+	err := db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "attempt_key"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"failures":     gorm.Expr("CASE WHEN login_attempts.last_failure < ? THEN 1 ELSE login_attempts.failures + 1 END", since),
+			"last_failure": at,
+		}),
+	}).Create(&attempt).Error
+	if err != nil {
+		return Counter{}, err
+	}
+
+	return s.Get(ctx, key)
+}
+
+func (s *DatabaseStore) Get(ctx context.Context, key string) (Counter, error) {
+	// most keys have no failures, Find doesn't log their missing row as an error like Take
+	attempts := []loginAttempt{}
+	if err := s.db.WithContext(ctx).Where("attempt_key = ?", key).Limit(1).Find(&attempts).Error; err != nil {
+		return Counter{}, err
+	}
+	if len(attempts) == 0 {
+		return Counter{}, nil
+	}
+	return Counter{Failures: attempts[0].Failures, LastFailure: attempts[0].LastFailure}, nil
+}
+
+func (s *DatabaseStore) Reset(ctx context.Context, key string) error {
+	return s.db.WithContext(ctx).Where("attempt_key = ?", key).Delete(&loginAttempt{}).Error
+}
@@ -0,0 +1,51 @@
+package attempts
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps the counters in the process, they are lost on restart and not shared between instances
+type MemoryStore struct {
+	mu       sync.Mutex
+	counters map[string]Counter
+}
+
+// NewMemory returns an empty MemoryStore
+func NewMemory() *MemoryStore {
+	return &MemoryStore{counters: map[string]Counter{}}
+}
+
+func (s *MemoryStore) Fail(ctx context.Context, key string, at, since time.Time) (Counter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// forgotten counters are removed, so the map doesn't grow forever
+	for k, counter := range s.counters {
+		if counter.LastFailure.Before(since) {
+			delete(s.counters, k)
+		}
+	}
+
+	counter := s.counters[key]
+	counter.Failures++
+	counter.LastFailure = at
+	s.counters[key] = counter
+	return counter, nil
+}
+
+func (s *MemoryStore) Get(ctx context.Context, key string) (Counter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.counters[key], nil
+}
+
+func (s *MemoryStore) Reset(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.counters, key)
+	return nil
+}
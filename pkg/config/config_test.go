@@ -0,0 +1,91 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(c *Config)
+		want   string
+	}{
+		{"valid", func(c *Config) {}, ""},
+		{"no server address", func(c *Config) { c.Server.Addr = "" }, "SERVER_ADDR is required"},
+		{"no database", func(c *Config) { c.Database.DSN = "" }, "DB_HOST (or DB_DSN) is required"},
+		{"no jwt secret", func(c *Config) { c.JWT.Secret = "" }, "JWT_SECRET (or JWT_KEYS or JWT_PRIVATE_KEY_FILES) is required"},
+		{"unknown key id", func(c *Config) { c.JWT.Keys = Keys{"2024": "secret"}; c.JWT.KeyID = "2023" }, `JWT_KEY_ID "2023" is not one of the ids`},
+		{"key id used twice", func(c *Config) {
+			c.JWT.Keys = Keys{"2024": "secret"}
+			c.JWT.PrivateKeyFiles = Keys{"2024": "key.pem"}
+			c.JWT.KeyID = "2024"
+		}, `key id "2024" is used by both`},
+		{"non-positive token ttl", func(c *Config) { c.JWT.AccessTokenTTL = 0 }, "JWT_ACCESS_TOKEN_TTL and JWT_REFRESH_TOKEN_TTL must be positive"},
+		{"unknown revocation store", func(c *Config) { c.JWT.RevocationStore = "redis" }, `JWT_REVOCATION_STORE "redis"`},
+		{"unknown storage driver", func(c *Config) { c.Storage.Driver = "ftp" }, `STORAGE_DRIVER "ftp"`},
+		{"cloudinary without credentials", func(c *Config) { c.Storage.Driver = "cloudinary" }, "CLOUDINARY_CLOUD_NAME is required"},
+		{"s3 without bucket", func(c *Config) { c.Storage.Driver = "s3"; c.Storage.S3.Endpoint = "localhost:9000" }, "S3_BUCKET is required"},
+		{"unknown mail driver", func(c *Config) { c.Mail.Driver = "pigeon" }, `MAIL_DRIVER "pigeon"`},
+		{"smtp without host", func(c *Config) { c.Mail.Driver = "smtp" }, "SMTP_HOST is required"},
+		{"invalid reset url", func(c *Config) { c.Auth.PasswordResetURL = "reset" }, "AUTH_PASSWORD_RESET_URL is not a valid URL"},
+		{"backoff above the lockout", func(c *Config) { c.Auth.LoginMaxBackoff = Duration(2 * time.Hour) }, "AUTH_LOGIN_BACKOFF must be positive"},
+		{"lockout not above the free attempts", func(c *Config) { c.Auth.AccountLockoutAttempts = 3 }, "AUTH_ACCOUNT_LOCKOUT_ATTEMPTS must be above"},
+		{"no password reset", func(c *Config) { c.Auth.PasswordResetIPLimit = 0 }, "AUTH_PASSWORD_RESET_IP_LIMIT"},
+		{"oidc without client", func(c *Config) {
+			c.OIDC.Issuer = "https://accounts.example.com"
+			c.OIDC.RedirectURL = "http://localhost:8082/api/v1/users/oidc/callback"
+		}, "OIDC_CLIENT_ID is required"},
+		{"unknown password hasher", func(c *Config) { c.Password.Hasher = "md5" }, `PASSWORD_HASHER "md5"`},
+		{"bcrypt cost too low", func(c *Config) { c.Password.Hasher = "bcrypt"; c.Password.BcryptCost = 3 }, "PASSWORD_BCRYPT_COST must be between 4 and 31"},
+		{"argon2 memory too low", func(c *Config) { c.Password.Argon2Memory = 4 }, "PASSWORD_ARGON2_MEMORY must be at least 8 KiB"},
+	}
+
+	for _, test := range tests {
+		config := valid()
+		test.change(&config)
+
+		err := config.Validate()
+		switch {
+		case test.want == "" && err != nil:
+			t.Errorf("%s: got %v, want it valid", test.name, err)
+		case test.want != "" && (err == nil || !strings.Contains(err.Error(), test.want)):
+			t.Errorf("%s: got %v, want an error containing %q", test.name, err, test.want)
+		}
+	}
+}
+
+func TestDatabaseValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		database Database
+		want     string
+	}{
+		{"dsn", Database{DSN: "sqlite://mygram.db"}, ""},
+		{"host settings", Database{Host: "localhost", User: "mygram", Name: "mygram"}, ""},
+		{"no dsn nor host", Database{User: "mygram", Name: "mygram"}, "DB_HOST (or DB_DSN) is required"},
+		{"no user", Database{Host: "localhost", Name: "mygram"}, "DB_USER (or DB_DSN) is required"},
+		{"no name", Database{Host: "localhost", User: "mygram"}, "DB_NAME (or DB_DSN) is required"},
+		{"negative pool", Database{DSN: "sqlite://mygram.db", MaxIdleConns: -1}, "can't be negative"},
+	}
+
+	for _, test := range tests {
+		err := test.database.Validate()
+		switch {
+		case test.want == "" && err != nil:
+			t.Errorf("%s: got %v, want it valid", test.name, err)
+		case test.want != "" && (err == nil || !strings.Contains(err.Error(), test.want)):
+			t.Errorf("%s: got %v, want an error containing %q", test.name, err, test.want)
+		}
+	}
+}
+
+// valid returns the defaults completed with the settings that have none
+func valid() Config {
+	config := Default()
+	config.Database.DSN = "sqlite://mygram.db"
+	config.JWT.Secret = "secret"
+	config.Storage.Driver = "local"
+	return config
+}
@@ -0,0 +1,132 @@
+package config
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+
+	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Load builds the configuration from the defaults, the optional YAML/TOML file at path,
+// the optional .env file and the environment, each one overriding the previous.
+// Without a path, the file is the one of CONFIG_FILE, which can be set in .env too.
+func Load(path string) (Config, error) {
+	return load(path, Config.Validate)
+}
+
+// LoadDatabase is Load for the commands that only use the database, the other settings aren't validated
+func LoadDatabase(path string) (Config, error) {
+	return load(path, func(c Config) error { return c.Database.Validate() })
+}
+
+func load(path string, validate func(Config) error) (Config, error) {
+	config := Default()
+
+	// .env never overrides the variables already set in the environment
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return config, fmt.Errorf("error loading .env file: %w", err)
+	}
+
+	if path == "" {
+		path = os.Getenv("CONFIG_FILE")
+	}
+	if path != "" {
+		if err := loadFile(path, &config); err != nil {
+			return config, err
+		}
+	}
+
+	if err := loadEnv(reflect.ValueOf(&config).Elem()); err != nil {
+		return config, err
+	}
+
+	if err := validate(config); err != nil {
+		return config, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	return config, nil
+}
+
+func loadFile(path string, config *Config) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading config file: %w", err)
+	}
+
+	switch ext := filepath.Ext(path); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, config)
+	case ".toml":
+		err = toml.Unmarshal(content, config)
+	default:
+		return fmt.Errorf("config file %s must be .yaml, .yml or .toml", path)
+	}
+
+	if err != nil {
+		return fmt.Errorf("error parsing config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// loadEnv sets every field tagged with env from the variable of the same name
+func loadEnv(value reflect.Value) error {
+	var errs []error
+
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Field(i)
+		name, tagged := value.Type().Field(i).Tag.Lookup("env")
+
+		if !tagged {
+			if field.Kind() == reflect.Struct {
+				if err := loadEnv(field); err != nil {
+					errs = append(errs, err)
+				}
+			}
+			continue
+		}
+
+		raw, ok := os.LookupEnv(name)
+		if !ok || raw == "" {
+			continue
+		}
+
+		if err := setField(field, raw); err != nil {
+			errs = append(errs, fmt.Errorf("%s %q is invalid: %w", name, raw, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func setField(field reflect.Value, raw string) error {
+	if unmarshaler, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return unmarshaler.UnmarshalText([]byte(raw))
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Int:
+		number, err := strconv.Atoi(raw)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(number))
+	case reflect.Bool:
+		boolean, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		field.SetBool(boolean)
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
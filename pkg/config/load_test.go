@@ -0,0 +1,194 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadPrecedence(t *testing.T) {
+	yamlFile := "server:\n  addr: \":9000\"\njwt:\n  access_token_ttl: 5m\n"
+	tomlFile := "[server]\naddr = \":9000\"\n\n[jwt]\naccess_token_ttl = \"5m\"\n"
+
+	tests := []struct {
+		name string
+		file string
+		body string
+		env  map[string]string
+		addr string
+		ttl  time.Duration
+	}{
+		{"defaults", "", "", nil, ":8082", 15 * time.Minute},
+		{"yaml file over the defaults", "config.yaml", yamlFile, nil, ":9000", 5 * time.Minute},
+		{"toml file over the defaults", "config.toml", tomlFile, nil, ":9000", 5 * time.Minute},
+		{"env over the file", "config.yaml", yamlFile, map[string]string{"SERVER_ADDR": ":9100"}, ":9100", 5 * time.Minute},
+		{"env over the defaults", "", "", map[string]string{"JWT_ACCESS_TOKEN_TTL": "1m"}, ":8082", time.Minute},
+		{"empty env keeps the file", "config.yaml", yamlFile, map[string]string{"SERVER_ADDR": ""}, ":9000", 5 * time.Minute},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			isolate(t)
+			for name, value := range test.env {
+				t.Setenv(name, value)
+			}
+
+			path := ""
+			if test.file != "" {
+				path = write(t, test.file, test.body)
+			}
+			config, err := Load(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if config.Server.Addr != test.addr {
+				t.Errorf("got SERVER_ADDR %s, want %s", config.Server.Addr, test.addr)
+			}
+			if got := time.Duration(config.JWT.AccessTokenTTL); got != test.ttl {
+				t.Errorf("got JWT_ACCESS_TOKEN_TTL %s, want %s", got, test.ttl)
+			}
+		})
+	}
+}
+
+func TestLoadReadsConfigFileAfterDotEnv(t *testing.T) {
+	isolate(t)
+	unsetenv(t, "CONFIG_FILE")
+	unsetenv(t, "JWT_AUDIENCE")
+	t.Setenv("SERVER_ADDR", ":9200")
+
+	file := write(t, "config.yaml", "server:\n  addr: \":9000\"\njwt:\n  issuer: file\n")
+	write(t, ".env", "CONFIG_FILE="+file+"\nSERVER_ADDR=:9300\nJWT_AUDIENCE=dotenv\n")
+
+	config, err := Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.JWT.Issuer != "file" {
+		t.Errorf("got JWT_ISSUER %s, want the one of the CONFIG_FILE set in .env", config.JWT.Issuer)
+	}
+	if config.JWT.Audience != "dotenv" {
+		t.Errorf("got JWT_AUDIENCE %s, want the one of .env", config.JWT.Audience)
+	}
+	// .env never overrides the environment
+	if config.Server.Addr != ":9200" {
+		t.Errorf("got SERVER_ADDR %s, want the one of the environment", config.Server.Addr)
+	}
+
+	// the path argument wins over CONFIG_FILE
+	other := write(t, "other.toml", "[jwt]\nissuer = \"argument\"\n")
+	config, err = Load(other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.JWT.Issuer != "argument" {
+		t.Errorf("got JWT_ISSUER %s, want the one of the path argument", config.JWT.Issuer)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		body string
+		env  map[string]string
+		want string
+	}{
+		{"unknown file extension", "config.json", "{}", nil, "must be .yaml, .yml or .toml"},
+		{"malformed file", "config.yaml", "server: [", nil, "error parsing config file"},
+		{"malformed duration in the file", "config.toml", "[server]\nread_timeout = \"soon\"\n", nil, "error parsing config file"},
+		{"malformed env", "", "", map[string]string{"SERVER_READ_TIMEOUT": "soon"}, `SERVER_READ_TIMEOUT "soon" is invalid`},
+		{"malformed keys", "", "", map[string]string{"JWT_KEYS": "secret"}, `JWT_KEYS "secret" is invalid`},
+		{"invalid settings", "", "", map[string]string{"STORAGE_DRIVER": "ftp"}, "invalid configuration"},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			isolate(t)
+			for name, value := range test.env {
+				t.Setenv(name, value)
+			}
+
+			path := ""
+			if test.file != "" {
+				path = write(t, test.file, test.body)
+			}
+			if _, err := Load(path); err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("got %v, want an error containing %q", err, test.want)
+			}
+		})
+	}
+
+	isolate(t)
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.yaml")); err == nil || !strings.Contains(err.Error(), "error reading config file") {
+		t.Errorf("missing file: got %v, want a read error", err)
+	}
+}
+
+func TestLoadDatabaseValidatesOnlyTheDatabase(t *testing.T) {
+	isolate(t)
+	t.Setenv("STORAGE_DRIVER", "ftp")
+
+	if _, err := LoadDatabase(""); err != nil {
+		t.Errorf("got %v, want the other settings ignored", err)
+	}
+
+	t.Setenv("DB_DSN", "")
+	if _, err := LoadDatabase(""); err == nil || !strings.Contains(err.Error(), "DB_HOST") {
+		t.Errorf("got %v, want the database settings required", err)
+	}
+}
+
+// isolate runs the test in an empty directory, so no .env is found, with only the variables a valid
+// configuration needs set, the others set in the environment of the test run are emptied
+func isolate(t *testing.T) {
+	t.Helper()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	prefixes := []string{"CONFIG_FILE", "SERVER_", "DB_", "JWT_", "AUTH_", "STORAGE_", "CLOUDINARY_", "S3_", "MAIL_", "SMTP_", "OIDC_", "PASSWORD_"}
+	for _, variable := range os.Environ() {
+		name, _, _ := strings.Cut(variable, "=")
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(name, prefix) {
+				t.Setenv(name, "")
+			}
+		}
+	}
+	t.Setenv("DB_DSN", "sqlite://mygram.db")
+	t.Setenv("JWT_SECRET", "secret")
+	t.Setenv("STORAGE_DRIVER", "local")
+}
+
+// unsetenv removes the variable for the duration of the test, so .env can set it
+func unsetenv(t *testing.T, name string) {
+	t.Helper()
+
+	t.Setenv(name, "")
+	os.Unsetenv(name)
+}
+
+// write creates the file in the current directory and returns its absolute path
+func write(t *testing.T, name, body string) string {
+	t.Helper()
+
+	path, err := filepath.Abs(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
@@ -1,36 +1,71 @@
-package database
-
-import (
-	"MyGramAPI/app/entity"
-	"fmt"
-	"log"
-	"os"
-
-	"gorm.io/driver/postgres"
-	"gorm.io/gorm"
-)
-
-var (
-	db       *gorm.DB
-	err      error
-)
-
-func Connect() (*gorm.DB, error){
-	host := os.Getenv("DB_HOST")
-	port := os.Getenv("DB_PORT")
-	user := os.Getenv("DB_USER")
-	password := os.Getenv("DB_PASSWORD")
-	dbname := os.Getenv("DB_NAME")
-
-	config := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s", host , port , user, password, dbname)
-
-	db, err := gorm.Open(postgres.Open(config), &gorm.Config{})
-
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	//create tables
-	db.Debug().AutoMigrate(entity.User{}, entity.Photo{}, entity.Comment{}, entity.SocialMedia{})
-	return db, err
-}
\ No newline at end of file
+package database
+
+import (
+	"MyGramAPI/pkg/config"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Connect opens the database once and configures its connection pool.
+// The returned handle is safe for concurrent use and should be shared by every handler.
+func Connect(cfg config.Database) (*gorm.DB, error) {
+	dialector, err := dialector(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := gorm.Open(dialector, &gorm.Config{
+		// unique violations become gorm.ErrDuplicatedKey, so they can be told apart from other failures
+		TranslateError: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	if db.Dialector.Name() == "sqlite" {
+		// a single connection avoids "database is locked" errors and keeps :memory: databases alive
+		sqlDB.SetMaxOpenConns(1)
+		return db, nil
+	}
+
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(time.Duration(cfg.ConnMaxLifetime))
+	sqlDB.SetConnMaxIdleTime(time.Duration(cfg.ConnMaxIdleTime))
+
+	return db, nil
+}
+
+// dialector picks the driver from the scheme of the DSN, example: sqlite://mygram.db, sqlite://:memory:
+func dialector(cfg config.Database) (gorm.Dialector, error) {
+	switch {
+	case strings.HasPrefix(cfg.DSN, "sqlite://"):
+		return sqlite.Open(strings.TrimPrefix(cfg.DSN, "sqlite://")), nil
+	case strings.HasPrefix(cfg.DSN, "postgres://"), strings.HasPrefix(cfg.DSN, "postgresql://"):
+		return postgres.Open(cfg.DSN), nil
+	case cfg.DSN != "":
+		return nil, fmt.Errorf("unsupported DB_DSN %q, it must start with sqlite:// or postgres://", cfg.DSN)
+	}
+
+	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s", cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.Name)
+	return postgres.Open(dsn), nil
+}
+
+// Close closes every connection of the pool
+func Close(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
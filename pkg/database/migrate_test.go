@@ -0,0 +1,116 @@
+package database
+
+import (
+	"MyGramAPI/pkg/config"
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestMigrateUpDownUp(t *testing.T) {
+	ctx := context.Background()
+	db, err := Connect(config.Database{DSN: "sqlite://" + filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Close(db)
+
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrations := migrator.migrations
+	latest := migrations[len(migrations)-1]
+
+	done, err := migrator.Up(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != len(migrations) {
+		t.Fatalf("got %d migrations applied, want %d", len(done), len(migrations))
+	}
+	checkStatus(t, migrator, len(migrations))
+
+	// nothing is left to apply
+	if done, err := migrator.Up(ctx); err != nil || len(done) != 0 {
+		t.Fatalf("second up: got %d migrations applied and %v, want none", len(done), err)
+	}
+
+	rolledBack, err := migrator.Down(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rolledBack == nil || rolledBack.Version != latest.Version {
+		t.Fatalf("got %+v rolled back, want migration %d", rolledBack, latest.Version)
+	}
+	checkStatus(t, migrator, len(migrations)-1)
+
+	done, err = migrator.Up(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != 1 || done[0].Version != latest.Version {
+		t.Fatalf("got %+v applied again, want migration %d", done, latest.Version)
+	}
+	checkStatus(t, migrator, len(migrations))
+
+	// every down file rolls back cleanly down to an empty schema, which migrates up again
+	for i := len(migrations) - 1; i >= 0; i-- {
+		rolledBack, err := migrator.Down(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rolledBack == nil || rolledBack.Version != migrations[i].Version {
+			t.Fatalf("got %+v rolled back, want migration %d", rolledBack, migrations[i].Version)
+		}
+	}
+	if rolledBack, err := migrator.Down(ctx); err != nil || rolledBack != nil {
+		t.Fatalf("down of an empty schema: got %+v and %v, want nothing", rolledBack, err)
+	}
+	if db.Migrator().HasTable("users") {
+		t.Error("the users table is left after rolling back every migration")
+	}
+	if done, err := migrator.Up(ctx); err != nil || len(done) != len(migrations) {
+		t.Fatalf("up after rolling back everything: got %d migrations applied and %v, want %d", len(done), err, len(migrations))
+	}
+}
+
+// TestMigrationSetsMatch keeps the migrations of both dialects in step, every change needs a file for each
+func TestMigrationSetsMatch(t *testing.T) {
+	postgres, err := LoadMigrations(migrationFiles, "migrations/postgres")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlite, err := LoadMigrations(migrationFiles, "migrations/sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(postgres) != len(sqlite) {
+		t.Fatalf("got %d postgres and %d sqlite migrations, want as many", len(postgres), len(sqlite))
+	}
+	for i := range postgres {
+		if postgres[i].Version != sqlite[i].Version || postgres[i].Name != sqlite[i].Name {
+			t.Errorf("got postgres migration %d_%s next to sqlite migration %d_%s",
+				postgres[i].Version, postgres[i].Name, sqlite[i].Version, sqlite[i].Name)
+		}
+		if postgres[i].Down == "" || sqlite[i].Down == "" {
+			t.Errorf("migration %d_%s has no down file for both dialects", postgres[i].Version, postgres[i].Name)
+		}
+	}
+}
+
+// checkStatus checks that the first applied migrations are applied and the others pending
+func checkStatus(t *testing.T, migrator *Migrator, applied int) {
+	t.Helper()
+
+	statuses, err := migrator.Status(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, status := range statuses {
+		if (status.AppliedAt != nil) != (i < applied) {
+			t.Errorf("migration %d_%s: got applied at %v, want applied %t", status.Version, status.Name, status.AppliedAt, i < applied)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+-- Baseline: the schema previously created by AutoMigrate.
+-- IF NOT EXISTS keeps it safe on databases that already have these tables.
+
+CREATE TABLE IF NOT EXISTS users (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    username text NOT NULL,
+    email text NOT NULL,
+    password text NOT NULL,
+    age bigint NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_username ON users (username);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email);
+
+CREATE TABLE IF NOT EXISTS photos (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    title text NOT NULL,
+    caption text NOT NULL,
+    photo_url text NOT NULL,
+    photo_key text,
+    user_id bigint
+);
+
+ALTER TABLE photos ADD COLUMN IF NOT EXISTS photo_key text;
+
+CREATE TABLE IF NOT EXISTS comments (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    user_id bigint,
+    photo_id bigint,
+    message text NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS social_media (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    name text NOT NULL,
+    social_media_url text NOT NULL,
+    user_id bigint
+);
@@ -0,0 +1,17 @@
+-- Refresh tokens: only the sha256 hash of a token is stored.
+-- Every token rotated from the same login shares its family_id, so a replayed token can revoke them all.
+
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    user_id bigint NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    family_id text NOT NULL,
+    token_hash text NOT NULL,
+    expires_at timestamptz NOT NULL,
+    used_at timestamptz,
+    revoked_at timestamptz
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_refresh_tokens_token_hash ON refresh_tokens (token_hash);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens (family_id);
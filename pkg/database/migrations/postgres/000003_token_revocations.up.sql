@@ -0,0 +1,16 @@
+-- Revoked access tokens, keyed by the jti claim and kept until the token expires.
+-- user_logouts holds the last "log out of all devices" of each user, older tokens are rejected.
+
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    jti text PRIMARY KEY,
+    user_id bigint NOT NULL,
+    expires_at timestamptz NOT NULL,
+    created_at timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expires_at ON revoked_tokens (expires_at);
+
+CREATE TABLE IF NOT EXISTS user_logouts (
+    user_id bigint PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    logged_out_at timestamptz NOT NULL
+);
@@ -0,0 +1,16 @@
+-- One-time tokens sent by mail, example: the password reset link.
+-- Only the sha256 hash of a token is stored, used_at makes it single-use.
+
+CREATE TABLE IF NOT EXISTS user_tokens (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    user_id bigint NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    purpose text NOT NULL,
+    token_hash text NOT NULL,
+    expires_at timestamptz NOT NULL,
+    used_at timestamptz
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_tokens_token_hash ON user_tokens (token_hash);
+CREATE INDEX IF NOT EXISTS idx_user_tokens_user_id_purpose ON user_tokens (user_id, purpose);
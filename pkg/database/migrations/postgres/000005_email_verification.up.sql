@@ -0,0 +1,6 @@
+-- Email verification: new accounts start unverified until the link sent on register is opened.
+-- The accounts created before this migration are considered verified, so they keep working.
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified boolean NOT NULL DEFAULT false;
+
+UPDATE users SET email_verified = true;
@@ -0,0 +1,23 @@
+-- Failed login attempts by key (account:<email> or ip:<address>), used for the backoff and the lockout.
+-- audit_logs records the security events, example: an account locked out after too many failed logins.
+
+CREATE TABLE IF NOT EXISTS login_attempts (
+    attempt_key text PRIMARY KEY,
+    failures integer NOT NULL,
+    last_failure timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_attempts_last_failure ON login_attempts (last_failure);
+
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    user_id bigint REFERENCES users (id) ON DELETE SET NULL,
+    action text NOT NULL,
+    ip text NOT NULL DEFAULT '',
+    details text NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_logs_user_id ON audit_logs (user_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_action ON audit_logs (action);
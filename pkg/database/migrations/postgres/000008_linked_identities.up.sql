@@ -0,0 +1,15 @@
+-- Linked identities: the accounts of OpenID Connect providers users log in with.
+-- The issuer and the subject of the ID token identify an account, a provider account is linked to one user only.
+
+CREATE TABLE IF NOT EXISTS linked_identities (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    user_id bigint NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    issuer text NOT NULL,
+    subject text NOT NULL,
+    email text NOT NULL DEFAULT ''
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_linked_identities_issuer_subject ON linked_identities (issuer, subject);
+CREATE INDEX IF NOT EXISTS idx_linked_identities_user_id ON linked_identities (user_id);
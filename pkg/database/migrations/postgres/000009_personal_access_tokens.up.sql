@@ -0,0 +1,17 @@
+-- Personal access tokens: long-lived tokens for scripts, limited to their scopes (separated by spaces).
+-- Only the sha256 hash of a token is stored, expires_at is null when the token never expires.
+
+CREATE TABLE IF NOT EXISTS personal_access_tokens (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    user_id bigint NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name text NOT NULL,
+    scopes text NOT NULL,
+    token_hash text NOT NULL,
+    expires_at timestamptz,
+    last_used_at timestamptz
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_personal_access_tokens_token_hash ON personal_access_tokens (token_hash);
+CREATE INDEX IF NOT EXISTS idx_personal_access_tokens_user_id ON personal_access_tokens (user_id);
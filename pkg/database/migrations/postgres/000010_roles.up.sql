@@ -0,0 +1,4 @@
+-- Roles: moderators can remove any photo or comment, admins can also manage the users.
+-- Every existing account becomes a normal user, the first admin is set with the role command.
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role text NOT NULL DEFAULT 'user';
@@ -0,0 +1,16 @@
+-- Sessions: one per login, shared by the refresh tokens of its family. The access tokens carry the session id,
+-- so removing the session logs the device out. The logins made before this migration get a session on their next refresh.
+
+CREATE TABLE IF NOT EXISTS sessions (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    user_id bigint NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    family_id text NOT NULL,
+    user_agent text NOT NULL,
+    ip text NOT NULL,
+    last_seen_at timestamptz NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sessions_family_id ON sessions (family_id);
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions (user_id);
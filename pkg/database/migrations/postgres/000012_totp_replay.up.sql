@@ -0,0 +1,4 @@
+-- TOTP replay protection: the time step of the last accepted code of a user, a code of that step or an earlier one
+-- is refused, so a code seen once can't log in again within its validity window.
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_last_step bigint NOT NULL DEFAULT 0;
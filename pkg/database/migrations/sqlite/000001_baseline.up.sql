@@ -0,0 +1,43 @@
+-- Baseline: the same schema as the postgres baseline, written for SQLite.
+
+CREATE TABLE IF NOT EXISTS users (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    username text NOT NULL,
+    email text NOT NULL,
+    password text NOT NULL,
+    age integer NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_username ON users (username);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email);
+
+CREATE TABLE IF NOT EXISTS photos (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    title text NOT NULL,
+    caption text NOT NULL,
+    photo_url text NOT NULL,
+    photo_key text,
+    user_id integer
+);
+
+CREATE TABLE IF NOT EXISTS comments (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    user_id integer,
+    photo_id integer,
+    message text NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS social_media (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    name text NOT NULL,
+    social_media_url text NOT NULL,
+    user_id integer
+);
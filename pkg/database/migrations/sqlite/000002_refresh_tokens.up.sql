@@ -0,0 +1,16 @@
+-- Refresh tokens: the same schema as the postgres migration, written for SQLite.
+
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    user_id integer NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    family_id text NOT NULL,
+    token_hash text NOT NULL,
+    expires_at datetime NOT NULL,
+    used_at datetime,
+    revoked_at datetime
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_refresh_tokens_token_hash ON refresh_tokens (token_hash);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens (family_id);
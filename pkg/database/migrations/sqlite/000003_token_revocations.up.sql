@@ -0,0 +1,15 @@
+-- Token revocations: the same schema as the postgres migration, written for SQLite.
+
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    jti text PRIMARY KEY,
+    user_id integer NOT NULL,
+    expires_at datetime NOT NULL,
+    created_at datetime
+);
+
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expires_at ON revoked_tokens (expires_at);
+
+CREATE TABLE IF NOT EXISTS user_logouts (
+    user_id integer PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    logged_out_at datetime NOT NULL
+);
@@ -0,0 +1,15 @@
+-- One-time tokens: the same schema as the postgres migration, written for SQLite.
+
+CREATE TABLE IF NOT EXISTS user_tokens (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    user_id integer NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    purpose text NOT NULL,
+    token_hash text NOT NULL,
+    expires_at datetime NOT NULL,
+    used_at datetime
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_tokens_token_hash ON user_tokens (token_hash);
+CREATE INDEX IF NOT EXISTS idx_user_tokens_user_id_purpose ON user_tokens (user_id, purpose);
@@ -0,0 +1,5 @@
+-- Email verification: the same change as the postgres migration, written for SQLite.
+
+ALTER TABLE users ADD COLUMN email_verified boolean NOT NULL DEFAULT false;
+
+UPDATE users SET email_verified = true;
@@ -0,0 +1,15 @@
+-- Two-factor authentication: the same schema as the postgres migration, written for SQLite.
+
+ALTER TABLE users ADD COLUMN totp_secret text NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN totp_enabled boolean NOT NULL DEFAULT false;
+
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    user_id integer NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    code_hash text NOT NULL,
+    used_at datetime
+);
+
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_user_id ON recovery_codes (user_id);
@@ -0,0 +1,22 @@
+-- Login protection: the same schema as the postgres migration, written for SQLite.
+
+CREATE TABLE IF NOT EXISTS login_attempts (
+    attempt_key text PRIMARY KEY,
+    failures integer NOT NULL,
+    last_failure datetime NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_attempts_last_failure ON login_attempts (last_failure);
+
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    user_id integer REFERENCES users (id) ON DELETE SET NULL,
+    action text NOT NULL,
+    ip text NOT NULL DEFAULT '',
+    details text NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_logs_user_id ON audit_logs (user_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_action ON audit_logs (action);
@@ -0,0 +1,14 @@
+-- Linked identities: the same schema as the postgres migration, written for SQLite.
+
+CREATE TABLE IF NOT EXISTS linked_identities (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    user_id integer NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    issuer text NOT NULL,
+    subject text NOT NULL,
+    email text NOT NULL DEFAULT ''
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_linked_identities_issuer_subject ON linked_identities (issuer, subject);
+CREATE INDEX IF NOT EXISTS idx_linked_identities_user_id ON linked_identities (user_id);
@@ -0,0 +1,16 @@
+-- Personal access tokens: the same schema as the postgres migration, written for SQLite.
+
+CREATE TABLE IF NOT EXISTS personal_access_tokens (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    user_id integer NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name text NOT NULL,
+    scopes text NOT NULL,
+    token_hash text NOT NULL,
+    expires_at datetime,
+    last_used_at datetime
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_personal_access_tokens_token_hash ON personal_access_tokens (token_hash);
+CREATE INDEX IF NOT EXISTS idx_personal_access_tokens_user_id ON personal_access_tokens (user_id);
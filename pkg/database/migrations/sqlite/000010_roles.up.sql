@@ -0,0 +1,3 @@
+-- Roles: the same change as the postgres migration, written for SQLite.
+
+ALTER TABLE users ADD COLUMN role text NOT NULL DEFAULT 'user';
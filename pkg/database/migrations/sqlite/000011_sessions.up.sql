@@ -0,0 +1,15 @@
+-- Sessions: the same schema as the postgres migration, written for SQLite.
+
+CREATE TABLE IF NOT EXISTS sessions (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    user_id integer NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    family_id text NOT NULL,
+    user_agent text NOT NULL,
+    ip text NOT NULL,
+    last_seen_at datetime NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sessions_family_id ON sessions (family_id);
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions (user_id);
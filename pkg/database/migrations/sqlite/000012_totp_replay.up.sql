@@ -0,0 +1,3 @@
+-- TOTP replay protection: the same change as the postgres migration, written for SQLite.
+
+ALTER TABLE users ADD COLUMN totp_last_step integer NOT NULL DEFAULT 0;
@@ -1,18 +1,31 @@
-package helpers
-
-import "golang.org/x/crypto/bcrypt"
-
-func HashPass(pass string) string {
-	salt := 6
-	password := []byte(pass)
-
-	hash, _ := bcrypt.GenerateFromPassword(password, salt)
-
-	return string(hash)
-}
-
-func ComparePass(h, p []byte) bool {
-	hash, pass := []byte(h), []byte(p)
-	err := bcrypt.CompareHashAndPassword(hash, pass)
-	return err == nil
-}
+package helpers
+
+import (
+	"MyGramAPI/pkg/config"
+	"MyGramAPI/pkg/password"
+)
+
+// passwords starts with the default settings, so the hooks work before InitPasswords is called
+var passwords, _ = password.New(config.Default().Password)
+
+// InitPasswords selects the algorithm and the parameters of the new password hashes
+func InitPasswords(cfg config.Password) error {
+	manager, err := password.New(cfg)
+	if err != nil {
+		return err
+	}
+	passwords = manager
+	return nil
+}
+
+// HashPass hashes the password with the configured algorithm
+func HashPass(pass string) (string, error) {
+	return passwords.Hash(pass)
+}
+
+// ComparePass reports whether the password matches the hash, rehash is true when the hash should be replaced
+// by HashPass since it was made by another algorithm or with other parameters
+func ComparePass(hash, pass string) (match, rehash bool) {
+	match, rehash, err := passwords.Verify(pass, hash)
+	return err == nil && match, rehash
+}
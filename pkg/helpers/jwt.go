@@ -1,50 +1,172 @@
-package helpers
-
-import (
-	"errors"
-	"os"
-	"strings"
-	"time"
-
-	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt"
-)
-
-var secretKey = os.Getenv("JWT_SECRET")
-
-func GenerateToken(id uint, email string, createdAt *time.Time) string {
-	claims := jwt.MapClaims{
-		"id":    id,
-		"email": email,
-		"created_at": createdAt,
-	}
-
-	parseToken := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signedToken, _ := parseToken.SignedString([]byte(secretKey))
-
-	return signedToken
-}
-
-func VerifyToken(c *gin.Context) (interface{}, error) {
-
-	errResponse := errors.New("sign in to proceed")
-	headerToken := c.Request.Header.Get("Authorization")
-	if bearer := strings.HasPrefix(headerToken, "Bearer"); !bearer {
-		return nil, errResponse
-	}
-
-	stringToken := strings.Split(headerToken, " ")[1]
-
-	token, _ := jwt.Parse(stringToken, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errResponse
-		}
-		return []byte(secretKey), nil
-	})
-
-	if _, ok := token.Claims.(jwt.MapClaims); !ok || !token.Valid {
-		return nil, errResponse
-	}
-
-	return token.Claims.(jwt.MapClaims), nil
-}
+package helpers
+
+import (
+	"MyGramAPI/pkg/config"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
+)
+
+// Claims are the claims of an access token, the subject is the id of the user
+type Claims struct {
+	jwt.StandardClaims
+	Email     string     `json:"email"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	// SessionID is the login the token was issued for, the token stops working once the session is removed
+	SessionID uint `json:"sid,omitempty"`
+	// Scopes are only set for the requests made with a personal access token, they are never read from a token
+	Scopes []string `json:"-"`
+}
+
+// UserID returns the id of the user the token was issued to
+func (c *Claims) UserID() uint {
+	id, _ := strconv.ParseUint(c.Subject, 10, 64)
+	return uint(id)
+}
+
+// AccessTokenClaims returns the claims of a request made with a personal access token of the user
+func AccessTokenClaims(userID uint, scopes []string) *Claims {
+	return &Claims{
+		StandardClaims: jwt.StandardClaims{Subject: strconv.FormatUint(uint64(userID), 10)},
+		Scopes:         scopes,
+	}
+}
+
+// signingKey is a key of the key set, its type decides the signing method
+type signingKey struct {
+	method jwt.SigningMethod
+	// private signs the tokens and public verifies them, both are the secret for HS256
+	private interface{}
+	public  interface{}
+}
+
+var (
+	keys           map[string]signingKey
+	keyID          string
+	issuer         string
+	audience       string
+	accessTokenTTL time.Duration
+)
+
+// InitJWT loads the keys used to sign and verify the tokens, the issuer, the audience and how long a token stays valid
+func InitJWT(cfg config.JWT) error {
+	keys = map[string]signingKey{}
+	keyID = cfg.KeyID
+
+	if len(cfg.Keys) == 0 && len(cfg.PrivateKeyFiles) == 0 {
+		keys["default"] = signingKey{method: jwt.SigningMethodHS256, private: []byte(cfg.Secret), public: []byte(cfg.Secret)}
+		keyID = "default"
+	}
+	for id, secret := range cfg.Keys {
+		keys[id] = signingKey{method: jwt.SigningMethodHS256, private: []byte(secret), public: []byte(secret)}
+	}
+	for id, file := range cfg.PrivateKeyFiles {
+		key, err := loadPrivateKey(file)
+		if err != nil {
+			return fmt.Errorf("error loading JWT key %s: %w", id, err)
+		}
+		keys[id] = key
+	}
+
+	issuer = cfg.Issuer
+	audience = cfg.Audience
+	accessTokenTTL = time.Duration(cfg.AccessTokenTTL)
+	return nil
+}
+
+// loadPrivateKey reads a PEM encoded RSA or Ed25519 private key
+func loadPrivateKey(file string) (signingKey, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return signingKey{}, err
+	}
+
+	if key, err := jwt.ParseRSAPrivateKeyFromPEM(content); err == nil {
+		return signingKey{method: jwt.SigningMethodRS256, private: key, public: &key.PublicKey}, nil
+	}
+
+	key, err := jwt.ParseEdPrivateKeyFromPEM(content)
+	if err != nil {
+		return signingKey{}, errors.New("the file is not a PEM encoded RSA or Ed25519 private key")
+	}
+	edKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return signingKey{}, errors.New("the file is not a PEM encoded RSA or Ed25519 private key")
+	}
+	return signingKey{method: jwt.SigningMethodEdDSA, private: edKey, public: edKey.Public()}, nil
+}
+
+func GenerateToken(id, sessionID uint, email string, createdAt *time.Time) string {
+	now := time.Now()
+	claims := &Claims{
+		StandardClaims: jwt.StandardClaims{
+			Id:        uuid.New().String(),
+			Subject:   strconv.FormatUint(uint64(id), 10),
+			Issuer:    issuer,
+			Audience:  audience,
+			IssuedAt:  now.Unix(),
+			NotBefore: now.Unix(),
+			ExpiresAt: now.Add(accessTokenTTL).Unix(),
+		},
+		Email:     email,
+		CreatedAt: createdAt,
+		SessionID: sessionID,
+	}
+
+	key := keys[keyID]
+	parseToken := jwt.NewWithClaims(key.method, claims)
+	parseToken.Header["kid"] = keyID
+	signedToken, _ := parseToken.SignedString(key.private)
+
+	return signedToken
+}
+
+func VerifyToken(c *gin.Context) (*Claims, error) {
+
+	errResponse := errors.New("sign in to proceed")
+	headerToken := c.Request.Header.Get("Authorization")
+	stringToken, bearer := strings.CutPrefix(headerToken, "Bearer ")
+	if !bearer {
+		return nil, errResponse
+	}
+
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(stringToken, claims, func(t *jwt.Token) (interface{}, error) {
+		// the kid header selects the key, so the keys can be rotated without logging everyone out
+		kid, _ := t.Header["kid"].(string)
+		key, ok := keys[kid]
+
+		// the method must be the one of the key, otherwise a public key could be used as an HS256 secret
+		if !ok || t.Method.Alg() != key.method.Alg() {
+			return nil, errResponse
+		}
+		return key.public, nil
+	})
+
+	if err != nil || !token.Valid || !claims.complete() {
+		return nil, errResponse
+	}
+
+	return claims, nil
+}
+
+// complete checks the claims jwt only verifies when they are present, every token we issue has all of them
+func (c *Claims) complete() bool {
+	now := time.Now().Unix()
+
+	return c.VerifyExpiresAt(now, true) &&
+		c.VerifyIssuedAt(now, true) &&
+		c.VerifyNotBefore(now, true) &&
+		c.VerifyIssuer(issuer, true) &&
+		c.VerifyAudience(audience, true) &&
+		c.Id != "" &&
+		c.UserID() != 0
+}
@@ -0,0 +1,203 @@
+package helpers
+
+import (
+	"MyGramAPI/pkg/config"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+)
+
+func TestVerifyTokenStandardClaims(t *testing.T) {
+	initJWT(t, config.JWT{Secret: "secret"})
+
+	tests := []struct {
+		name   string
+		change func(claims *Claims)
+		valid  bool
+	}{
+		{"valid", func(claims *Claims) {}, true},
+		{"expired", func(claims *Claims) { claims.ExpiresAt = time.Now().Add(-time.Second).Unix() }, false},
+		{"without expiry", func(claims *Claims) { claims.ExpiresAt = 0 }, false},
+		{"issued in the future", func(claims *Claims) { claims.IssuedAt = time.Now().Add(time.Hour).Unix() }, false},
+		{"not valid yet", func(claims *Claims) { claims.NotBefore = time.Now().Add(time.Hour).Unix() }, false},
+		{"other issuer", func(claims *Claims) { claims.Issuer = "someone-else" }, false},
+		{"without issuer", func(claims *Claims) { claims.Issuer = "" }, false},
+		{"other audience", func(claims *Claims) { claims.Audience = "other-api" }, false},
+		{"without audience", func(claims *Claims) { claims.Audience = "" }, false},
+		{"without id", func(claims *Claims) { claims.Id = "" }, false},
+		{"without subject", func(claims *Claims) { claims.Subject = "" }, false},
+	}
+
+	for _, test := range tests {
+		claims := validClaims()
+		test.change(claims)
+
+		_, err := verify(sign(t, jwt.SigningMethodHS256, []byte("secret"), "default", claims))
+		if (err == nil) != test.valid {
+			t.Errorf("%s: got %v, want valid %t", test.name, err, test.valid)
+		}
+	}
+
+	// the tokens we issue pass
+	claims, err := verify(GenerateToken(7, 3, "alice@example.com", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.UserID() != 7 || claims.SessionID != 3 || claims.Email != "alice@example.com" {
+		t.Errorf("got claims %+v, want user 7 of session 3", claims)
+	}
+}
+
+func TestVerifyTokenSelectsTheKeyByKid(t *testing.T) {
+	initJWT(t, config.JWT{Keys: config.Keys{"2024": "old secret", "2025": "new secret"}, KeyID: "2025"})
+
+	token := GenerateToken(1, 1, "alice@example.com", nil)
+	if kid := header(t, token)["kid"]; kid != "2025" {
+		t.Errorf("got a token signed with the key %v, want 2025", kid)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		valid bool
+	}{
+		{"new key", token, true},
+		{"previous key", sign(t, jwt.SigningMethodHS256, []byte("old secret"), "2024", validClaims()), true},
+		{"kid of another key", sign(t, jwt.SigningMethodHS256, []byte("old secret"), "2025", validClaims()), false},
+		{"unknown kid", sign(t, jwt.SigningMethodHS256, []byte("old secret"), "2023", validClaims()), false},
+		{"without kid", sign(t, jwt.SigningMethodHS256, []byte("new secret"), "", validClaims()), false},
+	}
+	for _, test := range tests {
+		if _, err := verify(test.token); (err == nil) != test.valid {
+			t.Errorf("%s: got %v, want valid %t", test.name, err, test.valid)
+		}
+	}
+
+	// once the previous key is removed its tokens are rejected
+	old := tests[1].token
+	initJWT(t, config.JWT{Keys: config.Keys{"2025": "new secret"}, KeyID: "2025"})
+	if _, err := verify(old); err == nil {
+		t.Error("a token of a removed key was accepted")
+	}
+	if _, err := verify(token); err != nil {
+		t.Errorf("a token of the current key was rejected: %v", err)
+	}
+}
+
+func TestVerifyTokenRejectsAnotherAlgorithm(t *testing.T) {
+	dir := t.TempDir()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaFile := writeKey(t, dir, "rsa.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))
+	initJWT(t, config.JWT{PrivateKeyFiles: config.Keys{"rsa": rsaFile}, KeyID: "rsa"})
+
+	token := GenerateToken(1, 1, "alice@example.com", nil)
+	if alg := header(t, token)["alg"]; alg != "RS256" {
+		t.Fatalf("got a token signed with %v, want RS256", alg)
+	}
+	if _, err := verify(token); err != nil {
+		t.Fatalf("a token of the RSA key was rejected: %v", err)
+	}
+
+	// the public key is published, so it must not work as an HS256 secret
+	publicDER, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})
+
+	forged := []string{
+		sign(t, jwt.SigningMethodHS256, publicPEM, "rsa", validClaims()),
+		sign(t, jwt.SigningMethodHS256, publicDER, "rsa", validClaims()),
+		sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, "rsa", validClaims()),
+	}
+	for _, token := range forged {
+		if _, err := verify(token); err == nil {
+			t.Errorf("the token signed with %v was accepted", header(t, token)["alg"])
+		}
+	}
+}
+
+// initJWT loads the keys with the issuer and audience of the defaults
+func initJWT(t *testing.T, cfg config.JWT) {
+	t.Helper()
+
+	defaults := config.Default().JWT
+	cfg.Issuer, cfg.Audience, cfg.AccessTokenTTL = defaults.Issuer, defaults.Audience, defaults.AccessTokenTTL
+	if err := InitJWT(cfg); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// validClaims returns the claims of a token issued now
+func validClaims() *Claims {
+	now := time.Now()
+	return &Claims{
+		StandardClaims: jwt.StandardClaims{
+			Id:        "jti",
+			Subject:   "1",
+			Issuer:    issuer,
+			Audience:  audience,
+			IssuedAt:  now.Unix(),
+			NotBefore: now.Unix(),
+			ExpiresAt: now.Add(time.Minute).Unix(),
+		},
+	}
+}
+
+// sign returns the token of the claims signed with the key, without a kid header when kid is empty
+func sign(t *testing.T, method jwt.SigningMethod, key interface{}, kid string, claims *Claims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+// header returns the decoded header of the token
+func header(t *testing.T, token string) map[string]interface{} {
+	t.Helper()
+
+	parsed, _, err := new(jwt.Parser).ParseUnverified(token, &Claims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed.Header
+}
+
+// verify runs VerifyToken on a request sending the token
+func verify(token string) (*Claims, error) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	c.Request.Header.Set("Authorization", "Bearer "+token)
+	return VerifyToken(c)
+}
+
+// writeKey writes the PEM encoded key to the directory and returns its path
+func writeKey(t *testing.T, dir, name, blockType string, der []byte) string {
+	t.Helper()
+
+	file := filepath.Join(dir, name)
+	if err := os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
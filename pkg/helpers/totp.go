@@ -0,0 +1,79 @@
+package helpers
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"image/png"
+	"strings"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+// GenerateTOTP returns a new TOTP secret for the account, the key holds the secret and its otpauth:// URI
+func GenerateTOTP(issuer, account string) (*otp.Key, error) {
+	return totp.Generate(totp.GenerateOpts{Issuer: issuer, AccountName: account})
+}
+
+// totpPeriod is how long a code is valid, the time step of a code is the Unix time divided by it
+const totpPeriod = 30
+
+// ValidateTOTP reports whether the code matches the secret and returns its time step,
+// the codes of the previous and next 30 seconds are accepted too
+func ValidateTOTP(code, secret string) (int64, bool) {
+	code = strings.TrimSpace(code)
+	current := time.Now().Unix() / totpPeriod
+
+	for step := current - 1; step <= current+1; step++ {
+		expected, err := totp.GenerateCode(secret, time.Unix(step*totpPeriod, 0))
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(code), []byte(expected)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// QRCode returns the QR code of the key as a PNG data URI, apps scan it to add the account
+func QRCode(key *otp.Key) (string, error) {
+	img, err := key.Image(256, 256)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return "", err
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// RecoveryCodes returns n random codes written as xxxxx-xxxxx and their hashes, only the hashes should be stored
+func RecoveryCodes(n int) ([]string, []string, error) {
+	codes := make([]string, n)
+	hashes := make([]string, n)
+
+	for i := range codes {
+		buf := make([]byte, 6)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, err
+		}
+
+		code := strings.ToLower(base32.StdEncoding.EncodeToString(buf))[:10]
+		codes[i] = code[:5] + "-" + code[5:]
+		hashes[i] = HashRecoveryCode(codes[i])
+	}
+	return codes, hashes, nil
+}
+
+// HashRecoveryCode returns the hash of a recovery code, the dash and the case are ignored so a code can be typed either way
+func HashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	return HashToken(code)
+}
@@ -0,0 +1,48 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FileMailer writes every mail to its own .eml file, so the mails can be read without a mail server
+type FileMailer struct {
+	dir  string
+	from *mail.Address
+}
+
+// NewFile returns a Mailer that writes the mails to dir, the directory is created if needed
+func NewFile(dir string, from *mail.Address) (*FileMailer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileMailer{dir: dir, from: from}, nil
+}
+
+func (m *FileMailer) Send(ctx context.Context, message Message) error {
+	// the timestamp keeps the files in the order they were sent
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102T150405.000000000"), uuid.New().String())
+	return os.WriteFile(filepath.Join(m.dir, name), format(m.from, message), 0o600)
+}
+
+// LogMailer prints the mails in the server log
+type LogMailer struct {
+	from *mail.Address
+}
+
+// NewLog returns a Mailer that prints the mails in the server log
+func NewLog(from *mail.Address) *LogMailer {
+	return &LogMailer{from: from}
+}
+
+func (m *LogMailer) Send(ctx context.Context, message Message) error {
+	log.Printf("mail to %s:\n%s", message.To, format(m.from, message))
+	return nil
+}
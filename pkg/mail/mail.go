@@ -0,0 +1,58 @@
+package mail
+
+import (
+	"MyGramAPI/pkg/config"
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net/mail"
+	"time"
+)
+
+// Message is a plain text mail
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends the mails of the account flows, example: the password reset link
+type Mailer interface {
+	Send(ctx context.Context, message Message) error
+}
+
+// New returns the Mailer selected by the mail driver
+func New(cfg config.Mail) (Mailer, error) {
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender address %q: %w", cfg.From, err)
+	}
+
+	switch cfg.Driver {
+	case "smtp":
+		return NewSMTP(cfg.SMTP.Host, cfg.SMTP.Port, cfg.SMTP.Username, cfg.SMTP.Password, from), nil
+	case "file":
+		return NewFile(cfg.File.Dir, from)
+	case "log":
+		return NewLog(from), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", cfg.Driver)
+	}
+}
+
+// format writes the message with its headers, the way it's sent to an SMTP server
+func format(from *mail.Address, message Message) []byte {
+	var b bytes.Buffer
+	to := mail.Address{Address: message.To}
+
+	fmt.Fprintf(&b, "From: %s\r\n", from.String())
+	fmt.Fprintf(&b, "To: %s\r\n", to.String())
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(message.Body)
+	return b.Bytes()
+}
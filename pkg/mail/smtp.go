@@ -0,0 +1,40 @@
+package mail
+
+import (
+	"context"
+	"net"
+	"net/mail"
+	"net/smtp"
+)
+
+// SMTPMailer sends the mails through an SMTP server
+type SMTPMailer struct {
+	addr string
+	auth smtp.Auth
+	from *mail.Address
+}
+
+// NewSMTP returns a Mailer that sends through host:port, the server isn't authenticated when username is empty
+func NewSMTP(host, port, username, password string, from *mail.Address) *SMTPMailer {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &SMTPMailer{addr: net.JoinHostPort(host, port), auth: auth, from: from}
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, message Message) error {
+	// smtp.SendMail doesn't take a context, so the mail is sent in the background and abandoned when ctx is done
+	result := make(chan error, 1)
+	go func() {
+		result <- smtp.SendMail(m.addr, m.auth, m.from.Address, []string{message.To}, format(m.from, message))
+	}()
+
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
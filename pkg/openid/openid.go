@@ -0,0 +1,149 @@
+package openid
+
+import (
+	"MyGramAPI/pkg/config"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+var (
+	// ErrInvalid is returned when the provider rejects the code or the ID token can't be trusted
+	ErrInvalid = errors.New("invalid authorization code or ID token")
+	// ErrUnavailable is returned when the provider can't be reached
+	ErrUnavailable = errors.New("OpenID Connect provider unavailable")
+)
+
+// Identity is the account of the provider the user logged in with, Issuer and Subject identify it
+type Identity struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	// Username is the preferred username of the provider, it may be empty or already taken here
+	Username string
+}
+
+// Provider logs users in with an OpenID Connect provider using the authorization code flow with PKCE
+type Provider struct {
+	issuer string
+	oauth2 oauth2.Config
+	client *http.Client
+
+	// the provider is discovered on the first login so the server starts while it's down
+	mu       sync.Mutex
+	verifier *oidc.IDTokenVerifier
+}
+
+// New returns the provider of the settings, it's nil when no issuer is configured
+func New(cfg config.OIDC) *Provider {
+	if cfg.Issuer == "" {
+		return nil
+	}
+
+	return &Provider{
+		issuer: cfg.Issuer,
+		oauth2: oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Scopes:       []string{oidc.ScopeOpenID, "email", "profile"},
+		},
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// AuthCodeURL returns the login page of the provider, the code it returns is only accepted with the verifier
+// and the ID token has to carry the nonce
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	if err := p.discover(ctx); err != nil {
+		return "", err
+	}
+
+	return p.oauth2.AuthCodeURL(state,
+		oidc.Nonce(nonce),
+		oauth2.SetAuthURLParam("code_challenge", challenge(verifier)),
+		oauth2.SetAuthURLParam("code_challenge_method", "S256"),
+	), nil
+}
+
+// Exchange trades the code returned by the provider for its ID token and returns the identity it proves
+func (p *Provider) Exchange(ctx context.Context, code, nonce, verifier string) (Identity, error) {
+	if err := p.discover(ctx); err != nil {
+		return Identity{}, err
+	}
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, p.client)
+
+	token, err := p.oauth2.Exchange(ctx, code, oauth2.SetAuthURLParam("code_verifier", verifier))
+	if err != nil {
+		var retrieve *oauth2.RetrieveError
+		if errors.As(err, &retrieve) && retrieve.Response.StatusCode < http.StatusInternalServerError {
+			return Identity{}, fmt.Errorf("%w: %v", ErrInvalid, err)
+		}
+		return Identity{}, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+
+	raw, ok := token.Extra("id_token").(string)
+	if !ok {
+		return Identity{}, fmt.Errorf("%w: the token response has no id_token", ErrInvalid)
+	}
+
+	idToken, err := p.verifier.Verify(oidc.ClientContext(ctx, p.client), raw)
+	if err != nil {
+		return Identity{}, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	// the nonce ties the ID token to the login started by this client, a token replayed from another login is refused
+	if idToken.Nonce != nonce {
+		return Identity{}, fmt.Errorf("%w: the nonce doesn't match", ErrInvalid)
+	}
+
+	var claims struct {
+		Email             string `json:"email"`
+		EmailVerified     bool   `json:"email_verified"`
+		PreferredUsername string `json:"preferred_username"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return Identity{}, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+
+	return Identity{
+		Issuer:        idToken.Issuer,
+		Subject:       idToken.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		Username:      claims.PreferredUsername,
+	}, nil
+}
+
+// discover loads the endpoints and the keys of the provider from its discovery document, a failure is retried on the next login
+func (p *Provider) discover(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.verifier != nil {
+		return nil
+	}
+
+	provider, err := oidc.NewProvider(oidc.ClientContext(ctx, p.client), p.issuer)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+
+	p.oauth2.Endpoint = provider.Endpoint()
+	p.verifier = provider.Verifier(&oidc.Config{ClientID: p.oauth2.ClientID})
+	return nil
+}
+
+// challenge is the S256 PKCE challenge of the verifier
+func challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,98 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+)
+
+// Argon2id hashes the passwords with argon2id, Memory is in KiB
+type Argon2id struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+}
+
+// argon2Params are the parameters read back from a hash
+type argon2Params struct {
+	memory, iterations uint32
+	parallelism        uint8
+	salt, key          []byte
+}
+
+func (a *Argon2id) Hash(password string) (string, error) {
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, a.Iterations, a.Memory, a.Parallelism, argon2KeyLength)
+
+	// the PHC string format, the salt and the key are base64 without padding
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, a.Memory, a.Iterations, a.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func (a *Argon2id) Verify(password, hash string) (bool, error) {
+	params, err := parseArgon2(hash)
+	if err != nil {
+		return false, err
+	}
+
+	// the hash is checked with its own parameters, they may be older than the current ones
+	key := argon2.IDKey([]byte(password), params.salt, params.iterations, params.memory, params.parallelism, uint32(len(params.key)))
+	return subtle.ConstantTimeCompare(key, params.key) == 1, nil
+}
+
+func (a *Argon2id) Identifies(hash string) bool {
+	return strings.HasPrefix(hash, "$argon2id$")
+}
+
+func (a *Argon2id) Outdated(hash string) bool {
+	params, err := parseArgon2(hash)
+	if err != nil {
+		return true
+	}
+	return params.memory != a.Memory || params.iterations != a.Iterations || params.parallelism != a.Parallelism ||
+		len(params.salt) != argon2SaltLength || len(params.key) != argon2KeyLength
+}
+
+// parseArgon2 reads the parameters, the salt and the key of a PHC string
+func parseArgon2(hash string) (argon2Params, error) {
+	params := argon2Params{}
+
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", salt, key
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, ErrUnknownHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, fmt.Errorf("%w: unsupported argon2 version %s", ErrUnknownHash, parts[2])
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.iterations, &params.parallelism); err != nil {
+		return params, fmt.Errorf("%w: %v", ErrUnknownHash, err)
+	}
+
+	var err error
+	if params.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return params, fmt.Errorf("%w: %v", ErrUnknownHash, err)
+	}
+	if params.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return params, fmt.Errorf("%w: %v", ErrUnknownHash, err)
+	}
+	if params.iterations < 1 || params.parallelism < 1 || len(params.key) == 0 {
+		return params, fmt.Errorf("%w: invalid argon2 parameters", ErrUnknownHash)
+	}
+	return params, nil
+}
@@ -0,0 +1,67 @@
+package password
+
+import (
+	"MyGramAPI/pkg/config"
+	"errors"
+	"fmt"
+)
+
+// ErrUnknownHash is returned when a stored hash wasn't made by any of the supported algorithms
+var ErrUnknownHash = errors.New("unknown password hash format")
+
+// Hasher hashes the passwords with one algorithm, the hashes are PHC strings like $argon2id$v=19$m=19456,t=2,p=1$salt$hash
+type Hasher interface {
+	Hash(password string) (string, error)
+	// Verify reports whether the password matches the hash, the hash must be one Identifies accepts
+	Verify(password, hash string) (bool, error)
+	// Identifies reports whether the hash was made by this algorithm
+	Identifies(hash string) bool
+	// Outdated reports whether the hash was made with other parameters than the current ones
+	Outdated(hash string) bool
+}
+
+// Manager hashes the new passwords with the configured hasher and verifies the hashes of every supported algorithm,
+// so the algorithm or its parameters can change without resetting the passwords
+type Manager struct {
+	current Hasher
+	hashers []Hasher
+}
+
+// New returns the manager of the settings
+func New(cfg config.Password) (*Manager, error) {
+	argon := &Argon2id{Memory: uint32(cfg.Argon2Memory), Iterations: uint32(cfg.Argon2Iterations), Parallelism: uint8(cfg.Argon2Parallelism)}
+	bcrypt := &Bcrypt{Cost: cfg.BcryptCost}
+
+	manager := &Manager{hashers: []Hasher{argon, bcrypt}}
+	switch cfg.Hasher {
+	case "argon2id":
+		manager.current = argon
+	case "bcrypt":
+		manager.current = bcrypt
+	default:
+		return nil, fmt.Errorf("unknown password hasher %q", cfg.Hasher)
+	}
+	return manager, nil
+}
+
+// Hash hashes the password with the configured hasher
+func (m *Manager) Hash(password string) (string, error) {
+	return m.current.Hash(password)
+}
+
+// Verify reports whether the password matches the hash, rehash is true when the hash should be replaced by a new one
+// since it was made by another algorithm or with other parameters
+func (m *Manager) Verify(password, hash string) (match, rehash bool, err error) {
+	for _, hasher := range m.hashers {
+		if !hasher.Identifies(hash) {
+			continue
+		}
+
+		match, err := hasher.Verify(password, hash)
+		if err != nil || !match {
+			return false, false, err
+		}
+		return true, hasher != m.current || hasher.Outdated(hash), nil
+	}
+	return false, false, ErrUnknownHash
+}
@@ -0,0 +1,179 @@
+package password
+
+import (
+	"MyGramAPI/pkg/config"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// cheap keeps the argon2id hashes of the tests fast
+var cheap = config.Password{Hasher: "argon2id", Argon2Memory: 64, Argon2Iterations: 1, Argon2Parallelism: 1, BcryptCost: 4}
+
+func TestArgon2idPHC(t *testing.T) {
+	argon := &Argon2id{Memory: 64, Iterations: 1, Parallelism: 2}
+
+	hash, err := argon.Hash("secret123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=2$") {
+		t.Fatalf("got hash %s, want a PHC string with the parameters", hash)
+	}
+
+	params, err := parseArgon2(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params.memory != 64 || params.iterations != 1 || params.parallelism != 2 || len(params.salt) != argon2SaltLength || len(params.key) != argon2KeyLength {
+		t.Errorf("got parameters %+v, want the ones of the hasher", params)
+	}
+
+	// the same password gets another salt every time
+	if other, _ := argon.Hash("secret123"); other == hash {
+		t.Error("got the same hash twice")
+	}
+
+	for password, want := range map[string]bool{"secret123": true, "secret124": false, "": false} {
+		if match, err := argon.Verify(password, hash); err != nil || match != want {
+			t.Errorf("%q: got match %t and %v, want %t", password, match, err, want)
+		}
+	}
+
+	// a hash is verified with its own parameters, not the current ones
+	changed := &Argon2id{Memory: 128, Iterations: 2, Parallelism: 1}
+	if match, err := changed.Verify("secret123", hash); err != nil || !match {
+		t.Errorf("hash of older parameters: got match %t and %v, want a match", match, err)
+	}
+}
+
+func TestParseArgon2RejectsMalformedHashes(t *testing.T) {
+	const salt, key = "c2FsdHNhbHRzYWx0c2FsdA", "a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2U"
+
+	tests := []struct {
+		name string
+		hash string
+	}{
+		{"empty", ""},
+		{"bcrypt", "$2a$04$abcdefghijklmnopqrstuu5Bx6Bz.Vx7f2Z8Oa0n3pBq6m0n2Jq3C"},
+		{"other variant", "$argon2i$v=19$m=64,t=1,p=1$" + salt + "$" + key},
+		{"missing key", "$argon2id$v=19$m=64,t=1,p=1$" + salt},
+		{"extra part", "$argon2id$v=19$m=64,t=1,p=1$" + salt + "$" + key + "$more"},
+		{"other version", "$argon2id$v=16$m=64,t=1,p=1$" + salt + "$" + key},
+		{"version not a number", "$argon2id$v=x$m=64,t=1,p=1$" + salt + "$" + key},
+		{"parameters not numbers", "$argon2id$v=19$m=x,t=1,p=1$" + salt + "$" + key},
+		{"missing parameter", "$argon2id$v=19$m=64,t=1$" + salt + "$" + key},
+		{"no iterations", "$argon2id$v=19$m=64,t=0,p=1$" + salt + "$" + key},
+		{"no parallelism", "$argon2id$v=19$m=64,t=1,p=0$" + salt + "$" + key},
+		{"salt not base64", "$argon2id$v=19$m=64,t=1,p=1$!!!$" + key},
+		{"padded key", "$argon2id$v=19$m=64,t=1,p=1$" + salt + "$" + key + "="},
+		{"empty key", "$argon2id$v=19$m=64,t=1,p=1$" + salt + "$"},
+	}
+
+	argon := &Argon2id{Memory: 64, Iterations: 1, Parallelism: 1}
+	for _, test := range tests {
+		if _, err := parseArgon2(test.hash); !errors.Is(err, ErrUnknownHash) {
+			t.Errorf("%s: got %v, want ErrUnknownHash", test.name, err)
+		}
+		if match, err := argon.Verify("secret123", test.hash); match || err == nil {
+			t.Errorf("%s: got match %t and %v, want an error", test.name, match, err)
+		}
+		if !argon.Outdated(test.hash) {
+			t.Errorf("%s: got the hash up to date, want it outdated", test.name)
+		}
+	}
+
+	if _, err := parseArgon2("$argon2id$v=19$m=64,t=1,p=1$" + salt + "$" + key); err != nil {
+		t.Errorf("well-formed hash: got %v", err)
+	}
+}
+
+func TestOutdated(t *testing.T) {
+	argon := &Argon2id{Memory: 64, Iterations: 1, Parallelism: 1}
+	hash, err := argon.Hash("secret123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bcrypt := &Bcrypt{Cost: 4}
+	bcryptHash, err := bcrypt.Hash("secret123")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		hasher Hasher
+		hash   string
+		want   bool
+	}{
+		{"same argon2id parameters", argon, hash, false},
+		{"more memory", &Argon2id{Memory: 128, Iterations: 1, Parallelism: 1}, hash, true},
+		{"more iterations", &Argon2id{Memory: 64, Iterations: 2, Parallelism: 1}, hash, true},
+		{"more parallelism", &Argon2id{Memory: 64, Iterations: 1, Parallelism: 2}, hash, true},
+		{"same bcrypt cost", bcrypt, bcryptHash, false},
+		{"higher bcrypt cost", &Bcrypt{Cost: 5}, bcryptHash, true},
+		{"bcrypt hash not bcrypt", bcrypt, "$2a$nope", true},
+	}
+	for _, test := range tests {
+		if got := test.hasher.Outdated(test.hash); got != test.want {
+			t.Errorf("%s: got outdated %t, want %t", test.name, got, test.want)
+		}
+	}
+}
+
+func TestManagerVerify(t *testing.T) {
+	manager, err := New(cheap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	current, err := manager.Hash("secret123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy, err := (&Bcrypt{Cost: 4}).Hash("secret123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	older, err := (&Argon2id{Memory: 32, Iterations: 1, Parallelism: 1}).Hash("secret123")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		hash     string
+		match    bool
+		rehash   bool
+		err      error
+	}{
+		{"current hash", "secret123", current, true, false, nil},
+		{"bcrypt hash", "secret123", legacy, true, true, nil},
+		{"older parameters", "secret123", older, true, true, nil},
+		// a wrong password never asks for a rehash, the hash isn't known to be the user's
+		{"wrong password of a bcrypt hash", "wrong", legacy, false, false, nil},
+		{"wrong password", "wrong", current, false, false, nil},
+		{"unknown algorithm", "secret123", "$md5$abc", false, false, ErrUnknownHash},
+	}
+	for _, test := range tests {
+		match, rehash, err := manager.Verify(test.password, test.hash)
+		if match != test.match || rehash != test.rehash || !errors.Is(err, test.err) {
+			t.Errorf("%s: got match %t, rehash %t and %v, want %t, %t and %v", test.name, match, rehash, err, test.match, test.rehash, test.err)
+		}
+	}
+
+	// switching back to bcrypt replaces the argon2id hashes
+	cheap := cheap
+	cheap.Hasher = "bcrypt"
+	manager, err = New(cheap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if match, rehash, err := manager.Verify("secret123", current); !match || !rehash || err != nil {
+		t.Errorf("argon2id hash with bcrypt configured: got match %t, rehash %t and %v, want a rehash", match, rehash, err)
+	}
+
+	if _, err := New(config.Password{Hasher: "md5"}); err == nil {
+		t.Error("unknown hasher: got no error")
+	}
+}
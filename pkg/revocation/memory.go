@@ -0,0 +1,58 @@
+package revocation
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps the revocations in the process, they are lost on restart and not shared between instances
+type MemoryStore struct {
+	mu         sync.RWMutex
+	tokens     map[string]time.Time
+	loggedOuts map[uint]time.Time
+}
+
+// NewMemory returns an empty MemoryStore
+func NewMemory() *MemoryStore {
+	return &MemoryStore{
+		tokens:     map[string]time.Time{},
+		loggedOuts: map[uint]time.Time{},
+	}
+}
+
+func (s *MemoryStore) Revoke(ctx context.Context, jti string, userID uint, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// expired tokens are rejected anyway, forget them so the map doesn't grow forever
+	now := time.Now()
+	for id, expiry := range s.tokens {
+		if expiry.Before(now) {
+			delete(s.tokens, id)
+		}
+	}
+
+	s.tokens[jti] = expiresAt
+	return nil
+}
+
+func (s *MemoryStore) RevokeUser(ctx context.Context, userID uint, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.loggedOuts[userID] = at
+	return nil
+}
+
+func (s *MemoryStore) IsRevoked(ctx context.Context, jti string, userID uint, issuedAt time.Time) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.tokens[jti]; ok {
+		return true, nil
+	}
+
+	loggedOutAt, ok := s.loggedOuts[userID]
+	return ok && revokedBy(issuedAt, loggedOutAt), nil
+}
@@ -0,0 +1,40 @@
+package revocation
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Store remembers the revoked access tokens, Authentication rejects a token the store reports as revoked
+type Store interface {
+	// Revoke rejects the token with the jti, expiresAt is when the token would be rejected anyway
+	Revoke(ctx context.Context, jti string, userID uint, expiresAt time.Time) error
+	// RevokeUser rejects every token of the user issued in a second before the given time, used with the removal of
+	// the sessions of the user to log out of all devices
+	RevokeUser(ctx context.Context, userID uint, at time.Time) error
+	// IsRevoked reports whether the token was revoked by itself or by RevokeUser
+	IsRevoked(ctx context.Context, jti string, userID uint, issuedAt time.Time) (bool, error)
+}
+
+// New returns the Store selected by the driver, database keeps the revocations in the given database
+func New(driver string, db *gorm.DB) (Store, error) {
+	switch driver {
+	case "database":
+		return NewDatabase(db), nil
+	case "memory":
+		return NewMemory(), nil
+	default:
+		return nil, fmt.Errorf("unknown revocation store %q", driver)
+	}
+}
+
+// revokedBy reports whether a token issued at issuedAt is covered by a logout of all devices at loggedOutAt.
+// Token timestamps have a precision of one second, so only the tokens issued in an earlier second are revoked here:
+// a token of the same second may have been issued right after the logout, like the one of a login after a password
+// reset. The tokens issued before the logout in that second are rejected because the logout removed their session.
+func revokedBy(issuedAt, loggedOutAt time.Time) bool {
+	return issuedAt.Before(loggedOutAt.Truncate(time.Second))
+}
@@ -0,0 +1,124 @@
+package revocation_test
+
+import (
+	"MyGramAPI/pkg/config"
+	"MyGramAPI/pkg/database"
+	"MyGramAPI/pkg/revocation"
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// stores returns a constructor of an empty store for every implementation, the database one runs on a migrated
+// SQLite database, so both are held to the same contract
+var stores = map[string]func(t *testing.T) revocation.Store{
+	"database": func(t *testing.T) revocation.Store {
+		db, err := database.Connect(config.Database{DSN: "sqlite://" + filepath.Join(t.TempDir(), "test.db")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { database.Close(db) })
+
+		migrator, err := database.NewMigrator(db)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := migrator.Up(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		return revocation.NewDatabase(db)
+	},
+	"memory": func(t *testing.T) revocation.Store {
+		return revocation.NewMemory()
+	},
+}
+
+func TestRevokeUser(t *testing.T) {
+	loggedOutAt := time.Date(2026, 1, 1, 12, 0, 0, 500*int(time.Millisecond), time.UTC)
+
+	// the tokens carry their issue time in whole seconds
+	tests := []struct {
+		name     string
+		issuedAt time.Time
+		revoked  bool
+	}{
+		{"issued a second before the logout", time.Date(2026, 1, 1, 11, 59, 59, 0, time.UTC), true},
+		{"issued an hour before the logout", loggedOutAt.Add(-time.Hour).Truncate(time.Second), true},
+		{"issued in the second of the logout", time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC), false},
+		{"issued after the logout", time.Date(2026, 1, 1, 12, 0, 1, 0, time.UTC), false},
+	}
+
+	for name, open := range stores {
+		open := open
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			store := open(t)
+
+			if err := store.RevokeUser(ctx, 1, loggedOutAt); err != nil {
+				t.Fatal(err)
+			}
+			for _, test := range tests {
+				revoked, err := store.IsRevoked(ctx, "jti", 1, test.issuedAt)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if revoked != test.revoked {
+					t.Errorf("%s: got revoked %t, want %t", test.name, revoked, test.revoked)
+				}
+
+				// the other users are not logged out
+				if revoked, err := store.IsRevoked(ctx, "jti", 2, test.issuedAt); err != nil || revoked {
+					t.Errorf("%s of another user: got revoked %t and %v, want it accepted", test.name, revoked, err)
+				}
+			}
+
+			// a later logout moves the limit
+			if err := store.RevokeUser(ctx, 1, loggedOutAt.Add(time.Hour)); err != nil {
+				t.Fatal(err)
+			}
+			if revoked, err := store.IsRevoked(ctx, "jti", 1, loggedOutAt.Add(time.Minute).Truncate(time.Second)); err != nil || !revoked {
+				t.Errorf("token issued before the second logout: got revoked %t and %v, want it revoked", revoked, err)
+			}
+		})
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	for name, open := range stores {
+		open := open
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			store := open(t)
+			issuedAt := time.Now().Truncate(time.Second)
+
+			// the logout of one session revokes its token only
+			if err := store.Revoke(ctx, "logged-out", 1, time.Now().Add(time.Hour)); err != nil {
+				t.Fatal(err)
+			}
+			// revoking twice is not an error
+			if err := store.Revoke(ctx, "logged-out", 1, time.Now().Add(time.Hour)); err != nil {
+				t.Fatal(err)
+			}
+
+			tests := []struct {
+				jti     string
+				userID  uint
+				revoked bool
+			}{
+				{"logged-out", 1, true},
+				{"other-session", 1, false},
+				{"other-user", 2, false},
+			}
+			for _, test := range tests {
+				revoked, err := store.IsRevoked(ctx, test.jti, test.userID, issuedAt)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if revoked != test.revoked {
+					t.Errorf("%s: got revoked %t, want %t", test.jti, revoked, test.revoked)
+				}
+			}
+		})
+	}
+}
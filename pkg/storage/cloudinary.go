@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/cloudinary/cloudinary-go"
+	"github.com/cloudinary/cloudinary-go/api/uploader"
+)
+
+// CloudinaryStore keeps the media in Cloudinary, the key is used as the public ID
+type CloudinaryStore struct {
+	cld *cloudinary.Cloudinary
+}
+
+// NewCloudinary connects to Cloudinary with the given credentials
+func NewCloudinary(cloudName, apiKey, apiSecret string) (*CloudinaryStore, error) {
+	if cloudName == "" || apiKey == "" || apiSecret == "" {
+		return nil, errors.New("cloudinary credentials are not set")
+	}
+
+	cld, err := cloudinary.NewFromParams(cloudName, apiKey, apiSecret)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to cloudinary: %w", err)
+	}
+
+	log.Println("cloudinary connected successfully")
+	return &CloudinaryStore{cld: cld}, nil
+}
+
+func (s *CloudinaryStore) Put(ctx context.Context, key string, r io.Reader) (string, error) {
+	resp, err := s.cld.Upload.Upload(ctx, r, uploader.UploadParams{
+		PublicID: key, // folder-name/file-name
+	})
+	if err != nil {
+		log.Printf("error uploading file to cloudinary: %v", err.Error())
+		return "", err
+	}
+
+	return resp.SecureURL, nil
+}
+
+func (s *CloudinaryStore) Delete(ctx context.Context, key string) error {
+	_, err := s.cld.Upload.Destroy(ctx, uploader.DestroyParams{
+		PublicID: key,
+	})
+	if err != nil {
+		log.Printf("error trying to delete file '%v' from cloudinary: %v", key, err.Error())
+	}
+	return err
+}
+
+func (s *CloudinaryStore) URL(ctx context.Context, key string) (string, error) {
+	image, err := s.cld.Image(key)
+	if err != nil {
+		return "", err
+	}
+	return image.String()
+}
+
+func (s *CloudinaryStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	url, err := s.URL(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("cloudinary responded with %s", resp.Status)
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalStoreRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocal(t.TempDir(), "http://localhost:8082/media/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := PhotoKey()
+	url, err := store.Put(ctx, key, strings.NewReader("photo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "http://localhost:8082/media/" + key; url != want {
+		t.Errorf("got URL %s, want %s", url, want)
+	}
+
+	if got := read(t, store, key); got != "photo" {
+		t.Errorf("got content %q, want photo", got)
+	}
+
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Open(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("open after delete: got %v, want ErrNotFound", err)
+	}
+
+	// deleting a missing key is not an error
+	if err := store.Delete(ctx, key); err != nil {
+		t.Errorf("delete of a missing key: %v", err)
+	}
+}
+
+func TestLocalStoreKeysStayInTheDirectory(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	dir := filepath.Join(root, "uploads")
+	store, err := NewLocal(dir, "/media")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "secret"), []byte("secret"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"../secret", "photos/../../secret", "/../secret"} {
+		if _, err := store.Open(ctx, key); !errors.Is(err, ErrNotFound) {
+			t.Errorf("open %s: got %v, want ErrNotFound", key, err)
+		}
+		if err := store.Delete(ctx, key); err != nil {
+			t.Errorf("delete %s: %v", key, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(root, "secret")); err != nil {
+		t.Errorf("the file outside the directory was touched: %v", err)
+	}
+
+	// a key climbing out is written inside the directory
+	if _, err := store.Put(ctx, "../escaped", strings.NewReader("photo")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "escaped")); err != nil {
+		t.Errorf("the media wasn't written inside the directory: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "escaped")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("the media was written outside the directory")
+	}
+
+	for _, key := range []string{"", "/", ".."} {
+		if _, err := store.Open(ctx, key); err == nil || errors.Is(err, ErrNotFound) {
+			t.Errorf("open %q: got %v, want the key to be rejected", key, err)
+		}
+	}
+
+	// a directory isn't a media
+	if _, err := store.Put(ctx, PhotoKey(), strings.NewReader("photo")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Open(ctx, "photos"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("open of a directory: got %v, want ErrNotFound", err)
+	}
+}
+
+// read returns the content of the media
+func read(t *testing.T, store MediaStore, key string) string {
+	t.Helper()
+
+	file, err := store.Open(context.Background(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Config holds the settings of an S3 compatible bucket
+type S3Config struct {
+	Endpoint  string
+	AccessKey string
+	SecretKey string
+	Bucket    string
+	Region    string
+	UseSSL    bool
+	// PublicURL is the base URL of a public bucket, it defaults to <endpoint>/<bucket>
+	PublicURL string
+	// PresignExpiry makes URL return presigned GET URLs, used for private buckets
+	PresignExpiry time.Duration
+}
+
+// S3Store keeps the media in an S3 compatible bucket (AWS S3, MinIO, ...), the key is used as the object name
+type S3Store struct {
+	client *minio.Client
+	config S3Config
+}
+
+// NewS3 connects to the bucket, the bucket is created if it doesn't exist
+func NewS3(config S3Config) (*S3Store, error) {
+	if config.Endpoint == "" || config.Bucket == "" {
+		return nil, fmt.Errorf("s3 endpoint and bucket are required")
+	}
+
+	client, err := minio.New(config.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(config.AccessKey, config.SecretKey, ""),
+		Secure: config.UseSSL,
+		Region: config.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to s3: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	exists, err := client.BucketExists(ctx, config.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("error checking s3 bucket %q: %w", config.Bucket, err)
+	}
+	if !exists {
+		err = client.MakeBucket(ctx, config.Bucket, minio.MakeBucketOptions{Region: config.Region})
+		if err != nil {
+			return nil, fmt.Errorf("error creating s3 bucket %q: %w", config.Bucket, err)
+		}
+	}
+
+	config.PublicURL = strings.TrimSuffix(config.PublicURL, "/")
+	return &S3Store{client: client, config: config}, nil
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, r io.Reader) (string, error) {
+	// the keys have no extension, so sniff the content type from the first bytes
+	reader := bufio.NewReader(r)
+	head, _ := reader.Peek(512)
+
+	_, err := s.client.PutObject(ctx, s.config.Bucket, key, reader, -1, minio.PutObjectOptions{
+		ContentType: http.DetectContentType(head),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return s.URL(ctx, key)
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	return s.client.RemoveObject(ctx, s.config.Bucket, key, minio.RemoveObjectOptions{})
+}
+
+func (s *S3Store) URL(ctx context.Context, key string) (string, error) {
+	if s.config.PresignExpiry > 0 {
+		url, err := s.client.PresignedGetObject(ctx, s.config.Bucket, key, s.config.PresignExpiry, nil)
+		if err != nil {
+			return "", err
+		}
+		return url.String(), nil
+	}
+
+	if s.config.PublicURL != "" {
+		return s.config.PublicURL + "/" + key, nil
+	}
+	return s.client.EndpointURL().String() + "/" + s.config.Bucket + "/" + key, nil
+}
+
+func (s *S3Store) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	// the bucket may hold more than the uploads, don't hand out the other objects
+	if !strings.HasPrefix(key, PhotoPrefix) {
+		return nil, ErrNotFound
+	}
+
+	object, err := s.client.GetObject(ctx, s.config.Bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	// GetObject is lazy, stat the object to find out whether it exists
+	if _, err := object.Stat(); err != nil {
+		object.Close()
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return object, nil
+}
@@ -0,0 +1,260 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestS3StoreOpensOnlyThePhotos(t *testing.T) {
+	// the keys are refused before the bucket is reached, so the store needs no client
+	store := &S3Store{}
+
+	for _, key := range []string{"", "backups/db.sql", "photo", "/photos/1b4e28ba"} {
+		if _, err := store.Open(context.Background(), key); !errors.Is(err, ErrNotFound) {
+			t.Errorf("open %q: got %v, want ErrNotFound", key, err)
+		}
+	}
+}
+
+func TestS3StoreRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	bucket := newS3Server()
+	server := httptest.NewServer(bucket)
+	defer server.Close()
+
+	store := newS3Store(t, server, S3Config{})
+	if !bucket.buckets["mygram"] {
+		t.Fatal("the bucket wasn't created")
+	}
+
+	key := PhotoKey()
+	png := "\x89PNG\r\n\x1a\nphoto"
+	url, err := store.Put(ctx, key, strings.NewReader(png))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := server.URL + "/mygram/" + key; url != want {
+		t.Errorf("got URL %s, want %s", url, want)
+	}
+	if got := bucket.objects["mygram/"+key]; got.content != png || got.contentType != "image/png" {
+		t.Errorf("got object %q of type %s, want the photo of type image/png", got.content, got.contentType)
+	}
+
+	if got := read(t, store, key); got != png {
+		t.Errorf("got content %q, want the photo", got)
+	}
+
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := bucket.objects["mygram/"+key]; ok {
+		t.Error("the object wasn't deleted")
+	}
+	if _, err := store.Open(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("open after delete: got %v, want ErrNotFound", err)
+	}
+
+	// deleting a missing key is not an error
+	if err := store.Delete(ctx, key); err != nil {
+		t.Errorf("delete of a missing key: %v", err)
+	}
+}
+
+func TestS3StoreURL(t *testing.T) {
+	ctx := context.Background()
+	bucket := newS3Server()
+	server := httptest.NewServer(bucket)
+	defer server.Close()
+
+	key := PhotoKey()
+	if _, err := newS3Store(t, server, S3Config{}).Put(ctx, key, strings.NewReader("photo")); err != nil {
+		t.Fatal(err)
+	}
+
+	public := newS3Store(t, server, S3Config{PublicURL: "https://cdn.mygram.example/"})
+	if got, err := public.URL(ctx, key); err != nil || got != "https://cdn.mygram.example/"+key {
+		t.Errorf("public URL: got %s and %v, want https://cdn.mygram.example/%s", got, err, key)
+	}
+
+	private := newS3Store(t, server, S3Config{PresignExpiry: time.Hour})
+	presigned, err := private.URL(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	link, err := url.Parse(presigned)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link.Path != "/mygram/"+key || link.Query().Get("X-Amz-Expires") != "3600" || link.Query().Get("X-Amz-Signature") == "" {
+		t.Errorf("got presigned URL %s, want a signed GET of the object expiring in an hour", presigned)
+	}
+
+	response, err := http.Get(presigned)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	content, _ := io.ReadAll(response.Body)
+	if response.StatusCode != http.StatusOK || string(content) != "photo" {
+		t.Errorf("presigned GET: got status %d and %q, want the photo", response.StatusCode, content)
+	}
+}
+
+// newS3Store connects a store to the stand-in with the config, the endpoint, bucket and keys are filled in
+func newS3Store(t *testing.T, server *httptest.Server, config S3Config) *S3Store {
+	t.Helper()
+
+	config.Endpoint = strings.TrimPrefix(server.URL, "http://")
+	config.Bucket = "mygram"
+	config.Region = "us-east-1"
+	config.AccessKey = "access"
+	config.SecretKey = "secret"
+	store, err := NewS3(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+type s3Object struct {
+	content     string
+	contentType string
+}
+
+// s3Server is a stand-in of an S3 bucket with path style URLs, it serves the calls made by S3Store and
+// ignores the signatures
+type s3Server struct {
+	mu      sync.Mutex
+	buckets map[string]bool
+	objects map[string]s3Object
+	uploads map[string]*s3Upload
+}
+
+type s3Upload struct {
+	contentType string
+	parts       map[int]string
+}
+
+func newS3Server() *s3Server {
+	return &s3Server{buckets: map[string]bool{}, objects: map[string]s3Object{}, uploads: map[string]*s3Upload{}}
+}
+
+func (s *s3Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	query := r.URL.Query()
+
+	if key == "" {
+		switch r.Method {
+		case http.MethodHead:
+			if !s.buckets[bucket] {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		case http.MethodPut:
+			s.buckets[bucket] = true
+		default:
+			w.WriteHeader(http.StatusNotImplemented)
+		}
+		return
+	}
+
+	name := bucket + "/" + key
+	switch {
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		id := strconv.Itoa(len(s.uploads) + 1)
+		s.uploads[id] = &s3Upload{contentType: r.Header.Get("Content-Type"), parts: map[int]string{}}
+		fmt.Fprintf(w, "<InitiateMultipartUploadResult><Bucket>%s</Bucket><Key>%s</Key><UploadId>%s</UploadId></InitiateMultipartUploadResult>", bucket, key, id)
+
+	case r.Method == http.MethodPut && query.Has("uploadId"):
+		upload, ok := s.uploads[query.Get("uploadId")]
+		part, err := strconv.Atoi(query.Get("partNumber"))
+		if !ok || err != nil {
+			s3Error(w, http.StatusNotFound, "NoSuchUpload")
+			return
+		}
+		upload.parts[part] = body(r)
+		w.Header().Set("ETag", fmt.Sprintf(`"%d"`, part))
+
+	case r.Method == http.MethodPost && query.Has("uploadId"):
+		upload, ok := s.uploads[query.Get("uploadId")]
+		if !ok {
+			s3Error(w, http.StatusNotFound, "NoSuchUpload")
+			return
+		}
+		content := ""
+		for part := 1; part <= len(upload.parts); part++ {
+			content += upload.parts[part]
+		}
+		s.objects[name] = s3Object{content: content, contentType: upload.contentType}
+		delete(s.uploads, query.Get("uploadId"))
+		fmt.Fprintf(w, `<CompleteMultipartUploadResult><Bucket>%s</Bucket><Key>%s</Key><ETag>"etag"</ETag></CompleteMultipartUploadResult>`, bucket, key)
+
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		object, ok := s.objects[name]
+		if !ok {
+			s3Error(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		w.Header().Set("Content-Type", object.contentType)
+		w.Header().Set("Content-Length", strconv.Itoa(len(object.content)))
+		w.Header().Set("ETag", `"etag"`)
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		if r.Method == http.MethodGet {
+			io.WriteString(w, object.content)
+		}
+
+	case r.Method == http.MethodDelete:
+		delete(s.objects, name)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
+
+func s3Error(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<Error><Code>%s</Code><Message>%s</Message></Error>", code, code)
+}
+
+// body returns the content of the request, decoding the aws-chunked encoding of the streaming signature
+func body(r *http.Request) string {
+	if r.Header.Get("X-Amz-Content-Sha256") != "STREAMING-AWS4-HMAC-SHA256-PAYLOAD" {
+		content, _ := io.ReadAll(r.Body)
+		return string(content)
+	}
+
+	// every chunk is <hex size>;chunk-signature=<signature>\r\n<data>\r\n, the last one is empty
+	reader := bufio.NewReader(r.Body)
+	content := ""
+	for {
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			return content
+		}
+		hex, _, _ := strings.Cut(strings.TrimSpace(header), ";")
+		size, err := strconv.ParseInt(hex, 16, 64)
+		if err != nil || size == 0 {
+			return content
+		}
+		chunk := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, chunk); err != nil {
+			return content
+		}
+		content += string(chunk[:size])
+	}
+}
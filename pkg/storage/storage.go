@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"MyGramAPI/pkg/config"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrNotFound is returned when the requested media doesn't exist
+var ErrNotFound = errors.New("media not found")
+
+// MediaStore stores the uploaded media under a key
+type MediaStore interface {
+	// Put saves the content under the key and returns its public URL
+	Put(ctx context.Context, key string, r io.Reader) (string, error)
+	// Delete removes the media, deleting a missing key is not an error
+	Delete(ctx context.Context, key string) error
+	// URL returns the URL the media can be downloaded from
+	URL(ctx context.Context, key string) (string, error)
+	// Open returns the content of the media
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+}
+
+// PhotoPrefix is the prefix of the keys of the uploaded photos
+const PhotoPrefix = "photos/"
+
+// PhotoKey returns a new unique key for a photo, example: photos/1b4e28ba-2fa1-11d2-883f-0016d3cca427
+func PhotoKey() string {
+	return PhotoPrefix + uuid.New().String()
+}
+
+// New returns the MediaStore selected by the storage driver
+func New(cfg config.Storage) (MediaStore, error) {
+	switch cfg.Driver {
+	case "cloudinary":
+		return newStore(NewCloudinary(cfg.Cloudinary.CloudName, cfg.Cloudinary.APIKey, cfg.Cloudinary.APISecret))
+	case "local":
+		return newStore(NewLocal(cfg.Local.Dir, cfg.Local.BaseURL))
+	case "s3":
+		return newStore(NewS3(S3Config{
+			Endpoint:      cfg.S3.Endpoint,
+			AccessKey:     cfg.S3.AccessKey,
+			SecretKey:     cfg.S3.SecretKey,
+			Bucket:        cfg.S3.Bucket,
+			Region:        cfg.S3.Region,
+			UseSSL:        cfg.S3.UseSSL,
+			PublicURL:     cfg.S3.PublicURL,
+			PresignExpiry: time.Duration(cfg.S3.PresignExpiry),
+		}))
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.Driver)
+	}
+}
+
+// newStore keeps a failed constructor from returning a non-nil interface holding a nil pointer
+func newStore[T MediaStore](store T, err error) (MediaStore, error) {
+	if err != nil {
+		return nil, err
+	}
+	return store, nil
+}
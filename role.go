@@ -0,0 +1,48 @@
+package main
+
+import (
+	"MyGramAPI/app/entity"
+	"MyGramAPI/app/repository"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// role runs the "role email user|moderator|admin" command, it's how the first admin is made
+func role(db *gorm.DB, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: mygram role email %s", strings.Join(entity.Roles, "|"))
+	}
+	email, role := args[0], args[1]
+
+	if !entity.ValidRole(role) {
+		return fmt.Errorf("unknown role %q, use %s", role, strings.Join(entity.Roles, ", "))
+	}
+
+	ctx := context.Background()
+	repos := repository.NewGorm(db)
+
+	User, err := repos.Users.FindByEmail(ctx, email)
+	if errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("no user is registered with the email %s", email)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := repos.Users.UpdateRole(ctx, User.ID, role); err != nil {
+		return err
+	}
+
+	AuditLog := entity.AuditLog{UserID: &User.ID, Action: entity.AuditRoleChanged, Details: "role set to " + role + " from the command line"}
+	if err := repos.AuditLogs.Create(ctx, &AuditLog); err != nil {
+		log.Printf("error recording audit log %s: %v", entity.AuditRoleChanged, err)
+	}
+
+	log.Printf("user %s is now %s", User.Username, role)
+	return nil
+}